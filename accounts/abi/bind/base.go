@@ -53,6 +53,9 @@ type TransactOpts struct {
 	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
 	GasLimit uint64   // Gas limit to set for the transaction execution (0 = estimate)
 
+	AccessList types.AccessList // Optional access list, sends an access list transaction if set
+	ChainID    *big.Int         // Chain to sign access list transactions for (mandatory with an access list)
+
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
@@ -230,23 +233,46 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 			}
 		}
 		// If the contract surely has code (or code is not needed), estimate the transaction
-		msg := luck.CallMsg{From: opts.From, To: contract, GasPrice: gasPrice, Value: value, Data: input}
+		msg := luck.CallMsg{From: opts.From, To: contract, GasPrice: gasPrice, Value: value, Data: input, AccessList: opts.AccessList}
 		gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
 		}
 	}
 	// Create the transaction, sign it and schedule it for execution
-	var rawTx *types.Transaction
-	if contract == nil {
+	var (
+		rawTx  *types.Transaction
+		signer types.Signer = types.HomesteadSigner{}
+	)
+	switch {
+	case opts.AccessList != nil:
+		if opts.ChainID == nil {
+			return nil, errors.New("no chain id to sign the access list transaction for")
+		}
+		var to *common.Address
+		if contract != nil {
+			to = &c.address
+		}
+		rawTx = types.NewTx(&types.AccessListTx{
+			ChainID:    opts.ChainID,
+			Nonce:      nonce,
+			GasPrice:   gasPrice,
+			Gas:        gasLimit,
+			To:         to,
+			Value:      value,
+			Data:       input,
+			AccessList: opts.AccessList,
+		})
+		signer = types.LatestSignerForChainID(opts.ChainID)
+	case contract == nil:
 		rawTx = types.NewContractCreation(nonce, value, gasLimit, gasPrice, input)
-	} else {
+	default:
 		rawTx = types.NewTransaction(nonce, c.address, value, gasLimit, gasPrice, input)
 	}
 	if opts.Signer == nil {
 		return nil, errors.New("no signer to authorize the transaction with")
 	}
-	signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, rawTx)
+	signedTx, err := opts.Signer(signer, opts.From, rawTx)
 	if err != nil {
 		return nil, err
 	}
@@ -781,3 +781,31 @@ func TestPackNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestPackAddressText(t *testing.T) {
+	typ, _ := NewType("address", "", nil)
+	addr := common.HexToAddress("0x00112233445566778899aabbccddeeff00112233445566778899")
+	want, _ := typ.pack(reflect.ValueOf(addr))
+
+	for i, input := range []string{addr.Hex(), strings.ToLower(addr.Hex()), addr.Bech32()} {
+		packed, err := typ.pack(reflect.ValueOf(input))
+		if err != nil {
+			t.Errorf("test %d: failed to pack %s: %v", i, input, err)
+		} else if !bytes.Equal(packed, want) {
+			t.Errorf("test %d: pack mismatch: have %x, want %x", i, packed, want)
+		}
+	}
+	// Checksum failures and short addresses must be rejected, not padded
+	broken := []byte(addr.Hex())
+	for i := 2; i < len(broken); i++ {
+		if broken[i] >= 'A' && broken[i] <= 'F' {
+			broken[i] += 'a' - 'A'
+			break
+		}
+	}
+	for i, input := range []string{string(broken), "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"} {
+		if _, err := typ.pack(reflect.ValueOf(input)); err == nil {
+			t.Errorf("test %d: invalid address %s packed", i, input)
+		}
+	}
+}
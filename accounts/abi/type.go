@@ -23,6 +23,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/luck/go-luck/common"
 )
 
 // Type enumerator
@@ -236,6 +238,15 @@ func (t Type) String() (out string) {
 func (t Type) pack(v reflect.Value) ([]byte, error) {
 	// dereference pointer first if it's a pointer
 	v = indirect(v)
+
+	// addresses given as text are parsed strictly, hex or bech32
+	if t.T == AddressTy && v.Kind() == reflect.String {
+		addr, err := common.ParseAddress(v.String())
+		if err != nil {
+			return nil, fmt.Errorf("abi: invalid address %q: %v", v.String(), err)
+		}
+		v = reflect.ValueOf(addr)
+	}
 	if err := typeCheck(t, v); err != nil {
 		return nil, err
 	}
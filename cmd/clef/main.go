@@ -352,10 +352,10 @@ func setCredential(ctx *cli.Context) error {
 		return err
 	}
 	addr := ctx.Args().First()
-	if !common.IsHexAddress(addr) {
-		utils.Fatalf("Invalid address specified: %s", addr)
+	address, err := common.ParseAddress(addr)
+	if err != nil {
+		utils.Fatalf("Invalid address specified: %s: %v", addr, err)
 	}
-	address := common.HexToAddress(addr)
 	password := getPassPhrase("Please enter a password to store for this address:", true)
 	fmt.Println()
 
@@ -382,10 +382,10 @@ func removeCredential(ctx *cli.Context) error {
 		return err
 	}
 	addr := ctx.Args().First()
-	if !common.IsHexAddress(addr) {
-		utils.Fatalf("Invalid address specified: %s", addr)
+	address, err := common.ParseAddress(addr)
+	if err != nil {
+		utils.Fatalf("Invalid address specified: %s: %v", addr, err)
 	}
-	address := common.HexToAddress(addr)
 
 	stretchedKey, err := readMasterKey(ctx, nil)
 	if err != nil {
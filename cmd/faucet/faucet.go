@@ -250,7 +250,7 @@ func newFaucet(genesis *core.Genesis, port int, lnodes []*discv5.Node, network u
 		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			var serv *les.LightLuck
 			ctx.Service(&serv)
-			return fortstats.New(stats, nil, serv)
+			return fortstats.New(stats, false, nil, serv)
 		}); err != nil {
 			return nil, err
 		}
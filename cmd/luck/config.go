@@ -70,7 +70,8 @@ var tomlSettings = toml.Config{
 }
 
 type fortstatsConfig struct {
-	URL string `toml:",omitempty"`
+	URL   string `toml:",omitempty"`
+	Tppow bool   `toml:",omitempty"`
 }
 
 type luckConfig struct {
@@ -130,6 +131,9 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, luckConfig) {
 	if ctx.GlobalIsSet(utils.EthStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.EthStatsTppowFlag.Name) {
+		cfg.Ethstats.Tppow = ctx.GlobalBool(utils.EthStatsTppowFlag.Name)
+	}
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 
 	return stack, cfg
@@ -176,7 +180,7 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	}
 	// Add the Luck Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
-		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
+		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL, cfg.Ethstats.Tppow)
 	}
 	return stack
 }
@@ -83,13 +83,20 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.TxPoolResnapshotFlag,
+		utils.TxPoolSnapshotLimitFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolFloorThresholdFlag,
+		utils.TxPoolFloorBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
+		utils.TxPoolAccountPendingFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateBlocksFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
@@ -145,6 +152,7 @@ var (
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
+		utils.EthStatsTppowFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
@@ -175,6 +183,7 @@ var (
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCap,
+		utils.RPCStrictAddressesFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -223,6 +232,7 @@ func init() {
 		// See misccmd.go:
 		makecacheCommand,
 		makedagCommand,
+		benchPowCommand,
 		versionCommand,
 		licenseCommand,
 		// See config.go
@@ -458,6 +468,10 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		if ctx.GlobalIsSet(utils.MinerThreadsFlag.Name) {
 			threads = ctx.GlobalInt(utils.MinerThreadsFlag.Name)
 		}
+		// Tppow is mined on local CPUs, so unless told otherwise use all of them
+		if !ctx.GlobalIsSet(utils.MinerThreadsFlag.Name) && !ctx.GlobalIsSet(utils.MinerLegacyThreadsFlag.Name) {
+			threads = runtime.NumCPU()
+		}
 		if err := luck.StartMining(threads); err != nil {
 			utils.Fatalf("Failed to start mining: %v", err)
 		}
@@ -18,13 +18,18 @@ package main
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/luck/go-luck/cmd/utils"
+	"github.com/luck/go-luck/common"
 	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/consensus/tppow"
+	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/fort"
 	"github.com/luck/go-luck/params"
 	"gopkg.in/urfave/cli.v1"
@@ -55,6 +60,26 @@ The makedag command generates an ethash DAG in <outputDir>.
 
 This command exists to support the system testing project.
 Regular users do not need to execute it.
+`,
+	}
+	benchPowCommand = cli.Command{
+		Action:    utils.MigrateFlags(benchPow),
+		Name:      "bench-pow",
+		Usage:     "Benchmark the Tppow seal stages on this machine",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.MinerThreadsFlag,
+			benchDurationFlag,
+		},
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The bench-pow command runs each Tppow seal stage (the first stage luck search,
+the luck derivation and the second stage block search) over synthetic headers
+on --miner.threads threads, reporting the hashrate and memory use of each.
+
+Using the difficulty the local chain would demand of its next block, it also
+estimates the average time this machine needs to seal a block on its own.
 `,
 	}
 	versionCommand = cli.Command{
@@ -76,6 +101,61 @@ The output of this command is supposed to be machine-readable.
 	}
 )
 
+var benchDurationFlag = cli.DurationFlag{
+	Name:  "duration",
+	Usage: "Time to run each seal stage for",
+	Value: 5 * time.Second,
+}
+
+// benchPow measures the Tppow hashrates of the local machine and estimates the
+// block time it would achieve at the difficulty of the local chain.
+func benchPow(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*tppow.Tppow)
+	if !ok {
+		utils.Fatalf("Chain is not sealed by Tppow")
+	}
+	parent := chain.CurrentHeader()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Time:       uint64(time.Now().Unix()),
+	}
+	if header.Time <= parent.Time {
+		header.Time = parent.Time + 1
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		utils.Fatalf("Failed to prepare benchmark header: %v", err)
+	}
+
+	var (
+		threads  = ctx.GlobalInt(utils.MinerThreadsFlag.Name)
+		duration = ctx.Duration(benchDurationFlag.Name)
+	)
+	fmt.Printf("Benchmarking Tppow at block %d, %v per stage\n", header.Number, duration)
+	result := tppow.Benchmark(chain.Config().Tppow, header.Number, threads, duration)
+
+	stats := new(runtime.MemStats)
+	runtime.ReadMemStats(stats)
+
+	fmt.Println("Threads:", result.Threads)
+	fmt.Printf("Luck search (SealLuck):      %.2f H/s\n", result.LuckRate)
+	fmt.Printf("Luck derivation (calcLuck):  %.2f H/s\n", result.CalcRate)
+	fmt.Printf("Block search (SealBlock):    %.2f H/s\n", result.BlockRate)
+	fmt.Printf("Memory: %s per hash, %s across threads, %s held by the process\n",
+		common.StorageSize(result.Memory), common.StorageSize(result.Memory*uint64(result.Threads)), common.StorageSize(stats.Sys))
+	fmt.Println("DifficultyAlpha:", header.DifficultyAlpha)
+	fmt.Println("Basis:", header.Basis)
+	fmt.Println("Expected block time:", result.ExpectedSealTime(header.DifficultyAlpha, header.Basis))
+	return nil
+}
+
 // makecache generates an ethash verification cache into the provided folder.
 func makecache(ctx *cli.Context) error {
 	args := ctx.Args()
@@ -79,6 +79,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
 			utils.EthStatsURLFlag,
+			utils.EthStatsTppowFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
@@ -123,13 +124,20 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolSnapshotFlag,
+			utils.TxPoolResnapshotFlag,
+			utils.TxPoolSnapshotLimitFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolFloorThresholdFlag,
+			utils.TxPoolFloorBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
+			utils.TxPoolAccountPendingFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolPrivateBlocksFlag,
 		},
 	},
 	{
@@ -162,6 +170,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
 			utils.RPCGlobalGasCap,
+			utils.RPCStrictAddressesFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
 			utils.WSEnabledFlag,
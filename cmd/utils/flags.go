@@ -39,6 +39,7 @@ import (
 	"github.com/luck/go-luck/consensus"
 	"github.com/luck/go-luck/consensus/clique"
 	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core"
 	"github.com/luck/go-luck/core/vm"
 	"github.com/luck/go-luck/crypto"
@@ -351,6 +352,21 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolSnapshotFlag = cli.StringFlag{
+		Name:  "txpool.snapshot",
+		Usage: "Disk snapshot of remote transactions to survive node restarts (empty = disabled)",
+		Value: core.DefaultTxPoolConfig.Snapshot,
+	}
+	TxPoolResnapshotFlag = cli.DurationFlag{
+		Name:  "txpool.resnapshot",
+		Usage: "Time interval to regenerate the remote transaction snapshot",
+		Value: core.DefaultTxPoolConfig.Resnapshot,
+	}
+	TxPoolSnapshotLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.snapshotlimit",
+		Usage: "Maximum number of transactions to snapshot or export",
+		Value: core.DefaultTxPoolConfig.SnapshotLimit,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -361,11 +377,26 @@ var (
 		Usage: "Price bump percentage to replace an already existing transaction",
 		Value: fort.DefaultConfig.TxPool.PriceBump,
 	}
+	TxPoolFloorThresholdFlag = cli.Uint64Flag{
+		Name:  "txpool.floorthreshold",
+		Usage: "Pending pool fill percentage above which the promotion fee floor starts rising (0 = disabled)",
+		Value: fort.DefaultConfig.TxPool.FloorThreshold,
+	}
+	TxPoolFloorBumpFlag = cli.Uint64Flag{
+		Name:  "txpool.floorbump",
+		Usage: "Percentage above the cheapest pooled price the fee floor reaches on a full pending pool",
+		Value: fort.DefaultConfig.TxPool.FloorBump,
+	}
 	TxPoolAccountSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.accountslots",
 		Usage: "Minimum number of executable transaction slots guaranteed per account",
 		Value: fort.DefaultConfig.TxPool.AccountSlots,
 	}
+	TxPoolAccountPendingFlag = cli.Uint64Flag{
+		Name:  "txpool.accountpending",
+		Usage: "Maximum number of executable transaction slots permitted per account (0 = unlimited)",
+		Value: fort.DefaultConfig.TxPool.AccountPending,
+	}
 	TxPoolGlobalSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.globalslots",
 		Usage: "Maximum number of executable transaction slots for all accounts",
@@ -386,6 +417,11 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: fort.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPrivateBlocksFlag = cli.Uint64Flag{
+		Name:  "txpool.privateblocks",
+		Usage: "Number of blocks private transactions are withheld from network propagation",
+		Value: fort.DefaultConfig.TxPool.PrivateBlocks,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -515,11 +551,19 @@ var (
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in fort_call/estimateGas",
 	}
+	RPCStrictAddressesFlag = cli.BoolFlag{
+		Name:  "rpc.strictaddr",
+		Usage: "Reject RPC calls with addresses that look like zero-padded 20 byte Ethereum addresses",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "fortstats",
 		Usage: "Reporting URL of a fortstats service (nodename:secret@host:port)",
 	}
+	EthStatsTppowFlag = cli.BoolFlag{
+		Name:  "fortstats.tppow",
+		Usage: "Extend fortstats reports with Tppow seal details (requires a compatible server)",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -1051,17 +1095,18 @@ func makeDatabaseHandles() int {
 	return int(raised / 2) // Leave half for networking and other stuff
 }
 
-// MakeAddress converts an account specified directly as a hex encoded string or
-// a key index in the key store to an internal account representation.
+// MakeAddress converts an account specified directly as a hex or bech32 encoded
+// string or a key index in the key store to an internal account representation.
 func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error) {
 	// If the specified account is a valid address, return it
-	if common.IsHexAddress(account) {
-		return accounts.Account{Address: common.HexToAddress(account)}, nil
+	address, addrErr := common.ParseAddress(account)
+	if addrErr == nil {
+		return accounts.Account{Address: address}, nil
 	}
 	// Otherwise try to interpret the account as a keystore index
 	index, err := strconv.Atoi(account)
 	if err != nil || index < 0 {
-		return accounts.Account{}, fmt.Errorf("invalid account address or index %q", account)
+		return accounts.Account{}, fmt.Errorf("invalid account address or index %q: %v", account, addrErr)
 	}
 	log.Warn("-------------------------------------------------------------------")
 	log.Warn("Referring to accounts by order in the keystore folder is dangerous!")
@@ -1218,6 +1263,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCStrictAddressesFlag.Name) {
+		cfg.RPCStrictAddresses = ctx.GlobalBool(RPCStrictAddressesFlag.Name)
+	}
 	if ctx.GlobalIsSet(InsecureUnlockAllowedFlag.Name) {
 		cfg.InsecureUnlockAllowed = ctx.GlobalBool(InsecureUnlockAllowedFlag.Name)
 	}
@@ -1279,11 +1327,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLocalsFlag.Name) {
 		locals := strings.Split(ctx.GlobalString(TxPoolLocalsFlag.Name), ",")
 		for _, account := range locals {
-			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
-				Fatalf("Invalid account in --txpool.locals: %s", trimmed)
-			} else {
-				cfg.Locals = append(cfg.Locals, common.HexToAddress(account))
+			trimmed := strings.TrimSpace(account)
+			address, err := common.ParseAddress(trimmed)
+			if err != nil {
+				Fatalf("Invalid account in --txpool.locals: %s: %v", trimmed, err)
 			}
+			cfg.Locals = append(cfg.Locals, address)
 		}
 	}
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
@@ -1295,15 +1344,33 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.GlobalString(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolResnapshotFlag.Name) {
+		cfg.Resnapshot = ctx.GlobalDuration(TxPoolResnapshotFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSnapshotLimitFlag.Name) {
+		cfg.SnapshotLimit = ctx.GlobalUint64(TxPoolSnapshotLimitFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
 	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolFloorThresholdFlag.Name) {
+		cfg.FloorThreshold = ctx.GlobalUint64(TxPoolFloorThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolFloorBumpFlag.Name) {
+		cfg.FloorBump = ctx.GlobalUint64(TxPoolFloorBumpFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolAccountPendingFlag.Name) {
+		cfg.AccountPending = ctx.GlobalUint64(TxPoolAccountPendingFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolGlobalSlotsFlag.Name) {
 		cfg.GlobalSlots = ctx.GlobalUint64(TxPoolGlobalSlotsFlag.Name)
 	}
@@ -1316,6 +1383,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPrivateBlocksFlag.Name) {
+		cfg.PrivateBlocks = ctx.GlobalUint64(TxPoolPrivateBlocksFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *fort.Config) {
@@ -1343,6 +1413,9 @@ func setEthash(ctx *cli.Context, cfg *fort.Config) {
 	if ctx.GlobalIsSet(EthashDatasetsLockMmapFlag.Name) {
 		cfg.Ethash.DatasetsLockMmap = ctx.GlobalBool(EthashDatasetsLockMmapFlag.Name)
 	}
+	if ctx.GlobalBool(FakePoWFlag.Name) {
+		cfg.Ethash.PowMode = ethash.ModeFake
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1630,8 +1703,9 @@ func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 }
 
 // RegisterEthStatsService configures the Luck Stats daemon and adds it to
-// the given node.
-func RegisterEthStatsService(stack *node.Node, url string) {
+// the given node. If tppow is set, the reports are extended with the Tppow seal
+// details.
+func RegisterEthStatsService(stack *node.Node, url string, tppow bool) {
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		// Retrieve both fort and les services
 		var fortServ *fort.Luck
@@ -1641,7 +1715,7 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 		ctx.Service(&lesServ)
 
 		// Let fortstats use whichever is not nil
-		return fortstats.New(url, fortServ, lesServ)
+		return fortstats.New(url, tppow, fortServ, lesServ)
 	}); err != nil {
 		Fatalf("Failed to register the Luck Stats service: %v", err)
 	}
@@ -1749,18 +1823,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
 	} else {
-		engine = ethash.NewFaker()
+		engine = tppow.NewFakerWithConfig(config.Tppow)
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
-			engine = ethash.New(ethash.Config{
-				CacheDir:         stack.ResolvePath(fort.DefaultConfig.Ethash.CacheDir),
-				CachesInMem:      fort.DefaultConfig.Ethash.CachesInMem,
-				CachesOnDisk:     fort.DefaultConfig.Ethash.CachesOnDisk,
-				CachesLockMmap:   fort.DefaultConfig.Ethash.CachesLockMmap,
-				DatasetDir:       stack.ResolvePath(fort.DefaultConfig.Ethash.DatasetDir),
-				DatasetsInMem:    fort.DefaultConfig.Ethash.DatasetsInMem,
-				DatasetsOnDisk:   fort.DefaultConfig.Ethash.DatasetsOnDisk,
-				DatasetsLockMmap: fort.DefaultConfig.Ethash.DatasetsLockMmap,
-			}, nil, false)
+			engine = tppow.New(config.Tppow, nil, false)
 		}
 	}
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
@@ -0,0 +1,159 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"errors"
+	"strings"
+)
+
+// bech32Charset is the alphabet of the 5 bit groups of a bech32 string.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var (
+	errBech32Length    = errors.New("bech32 string too long or too short")
+	errBech32Case      = errors.New("bech32 string has mixed case")
+	errBech32Separator = errors.New("bech32 separator missing")
+	errBech32Char      = errors.New("bech32 string has invalid character")
+	errBech32Checksum  = errors.New("bech32 checksum mismatch")
+	errBech32Padding   = errors.New("bech32 data has invalid padding")
+)
+
+// bech32Polymod computes the BCH checksum of a sequence of 5 bit groups, as
+// specified by BIP-173.
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32HRPExpand expands the human readable part for checksum computation.
+func bech32HRPExpand(hrp string) []byte {
+	res := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		res = append(res, hrp[i]>>5)
+	}
+	res = append(res, 0)
+	for i := 0; i < len(hrp); i++ {
+		res = append(res, hrp[i]&31)
+	}
+	return res
+}
+
+// bech32Checksum calculates the six 5 bit groups of checksum over the human
+// readable part and data.
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	mod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ 1
+
+	res := make([]byte, 6)
+	for i := range res {
+		res[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return res
+}
+
+// convertBits regroups a byte slice from groups of from bits to groups of to
+// bits, padding the last group with zeroes if pad is set.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var (
+		acc  uint32
+		bits uint
+		res  []byte
+		max  = uint32(1<<to) - 1
+	)
+	for _, b := range data {
+		acc = acc<<from | uint32(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			res = append(res, byte(acc>>bits&max))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			res = append(res, byte(acc<<(to-bits)&max))
+		}
+	} else if bits >= from || acc<<(to-bits)&max != 0 {
+		return nil, errBech32Padding
+	}
+	return res, nil
+}
+
+// bech32Encode encodes a byte slice into a bech32 string with the given human
+// readable part.
+func bech32Encode(hrp string, data []byte) string {
+	groups, _ := convertBits(data, 8, 5, true)
+	groups = append(groups, bech32Checksum(hrp, groups)...)
+
+	var b strings.Builder
+	b.Grow(len(hrp) + 1 + len(groups))
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, g := range groups {
+		b.WriteByte(bech32Charset[g])
+	}
+	return b.String()
+}
+
+// bech32Decode decodes a bech32 string, verifying its checksum and returning
+// the human readable part along with the decoded bytes.
+func bech32Decode(s string) (string, []byte, error) {
+	if len(s) < 8 || len(s) > 90 {
+		return "", nil, errBech32Length
+	}
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, errBech32Case
+	}
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || sep+7 > len(lower) {
+		return "", nil, errBech32Separator
+	}
+	hrp := lower[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errBech32Char
+		}
+	}
+	groups := make([]byte, 0, len(lower)-sep-1)
+	for i := sep + 1; i < len(lower); i++ {
+		g := strings.IndexByte(bech32Charset, lower[i])
+		if g < 0 {
+			return "", nil, errBech32Char
+		}
+		groups = append(groups, byte(g))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), groups...)) != 1 {
+		return "", nil, errBech32Checksum
+	}
+	data, err := convertBits(groups[:len(groups)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
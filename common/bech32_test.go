@@ -0,0 +1,76 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests the checksum validation against the BIP-173 test vectors.
+func TestBech32Vectors(t *testing.T) {
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	}
+	for _, s := range valid {
+		hrp, _, err := bech32Decode(s)
+		if err != nil && err != errBech32Padding {
+			t.Errorf("%s: failed to decode: %v", s, err)
+			continue
+		}
+		if hrp != strings.ToLower(s[:strings.LastIndexByte(s, '1')]) {
+			t.Errorf("%s: hrp mismatch: have %s", s, hrp)
+		}
+	}
+	invalid := []string{
+		"pzry9x0s0muk",  // no separator
+		"1pzry9x0s0muk", // empty hrp
+		"x1b4n0q5v",     // invalid data character
+		"li1dgmt3",      // checksum too short
+		"A1G7SGD8",      // checksum computed with uppercase hrp
+		"10a06t8",       // empty hrp
+		"1qzzfhee",      // empty hrp
+		"a12UEL5L",      // mixed case
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx", // bad checksum
+	}
+	for _, s := range invalid {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Errorf("%s: invalid string decoded", s)
+		}
+	}
+}
+
+// Tests that arbitrary data survives a bech32 round-trip.
+func TestBech32RoundTrip(t *testing.T) {
+	for n := 0; n <= 40; n++ {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*37 + n)
+		}
+		hrp, dec, err := bech32Decode(bech32Encode("test", data))
+		if err != nil {
+			t.Fatalf("length %d: failed to decode: %v", n, err)
+		}
+		if hrp != "test" || string(dec) != string(data) {
+			t.Errorf("length %d: round-trip mismatch: have %s/%x, want test/%x", n, hrp, dec, data)
+		}
+	}
+}
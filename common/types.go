@@ -39,11 +39,36 @@ const (
 	AddressLength = 25
 )
 
+// AddressHRP is the human readable part prefixing the bech32 text encoding of
+// addresses.
+const AddressHRP = "luck"
+
 var (
 	hashT    = reflect.TypeOf(Hash{})
 	addressT = reflect.TypeOf(Address{})
 )
 
+var (
+	// ErrAddressLength is returned when parsing an address of the wrong size.
+	ErrAddressLength = fmt.Errorf("address must be %d bytes", AddressLength)
+
+	// ErrEthereumAddress is returned when parsing a 20 byte address, which can't
+	// belong to a Luck account.
+	ErrEthereumAddress = fmt.Errorf("20 byte Ethereum style address, Luck addresses are %d bytes", AddressLength)
+
+	// ErrAddressSyntax is returned when parsing an address containing characters
+	// invalid for its encoding.
+	ErrAddressSyntax = errors.New("invalid address characters")
+
+	// ErrAddressChecksum is returned when parsing a mixed-case hex address whose
+	// capitalisation doesn't match its checksum.
+	ErrAddressChecksum = errors.New("invalid address checksum")
+
+	// ErrAddressPrefix is returned when parsing a bech32 address with a human
+	// readable part other than AddressHRP.
+	ErrAddressPrefix = fmt.Errorf("address prefix must be %q", AddressHRP)
+)
+
 // Hash represents the 32 byte Keccak256 hash of arbitrary data.
 type Hash [HashLength]byte
 
@@ -191,6 +216,66 @@ func BigToAddress(b *big.Int) Address { return BytesToAddress(b.Bytes()) }
 // If s is larger than len(h), s will be cropped from the left.
 func HexToAddress(s string) Address { return BytesToAddress(FromHex(s)) }
 
+// ParseAddress strictly parses an address from either its hex or its bech32 text
+// encoding. Unlike HexToAddress it never crops or pads the input, and it rejects
+// mixed-case hex addresses whose capitalisation doesn't match the checksum.
+func ParseAddress(s string) (Address, error) {
+	if strings.HasPrefix(strings.ToLower(s), AddressHRP+"1") {
+		return parseBech32Address(s)
+	}
+	var a Address
+	hexstr := s
+	if has0xPrefix(hexstr) {
+		hexstr = hexstr[2:]
+	}
+	if !isHex(hexstr) {
+		return a, ErrAddressSyntax
+	}
+	switch len(hexstr) {
+	case 2 * AddressLength:
+	case 2 * 20:
+		return a, ErrEthereumAddress
+	default:
+		return a, ErrAddressLength
+	}
+	hex.Decode(a[:], []byte(hexstr))
+	if err := a.verifyChecksum(hexstr); err != nil {
+		return Address{}, err
+	}
+	return a, nil
+}
+
+// parseBech32Address decodes an address from its bech32 text encoding.
+func parseBech32Address(s string) (Address, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return Address{}, fmt.Errorf("%v: %v", ErrAddressSyntax, err)
+	}
+	if hrp != AddressHRP {
+		return Address{}, ErrAddressPrefix
+	}
+	if len(data) != AddressLength {
+		if len(data) == 20 {
+			return Address{}, ErrEthereumAddress
+		}
+		return Address{}, ErrAddressLength
+	}
+	return BytesToAddress(data), nil
+}
+
+// verifyChecksum checks the capitalisation of the unprefixed hex representation
+// of the address. All lower- and all upper-case inputs carry no checksum and are
+// accepted as is.
+func (a Address) verifyChecksum(hexstr string) error {
+	if strings.ToLower(hexstr) == hexstr || strings.ToUpper(hexstr) == hexstr {
+		return nil
+	}
+	if a.Hex()[2:] != hexstr {
+		return ErrAddressChecksum
+	}
+	return nil
+}
+
 // IsHexAddress verifies whforter a string can represent a valid hex-encoded
 // Luck address or not.
 func IsHexAddress(s string) bool {
@@ -228,6 +313,12 @@ func (a Address) Hex() string {
 	return "0x" + string(result)
 }
 
+// Bech32 returns the bech32 text encoding of the address, prefixed by AddressHRP
+// and carrying a checksum over all of its characters.
+func (a Address) Bech32() string {
+	return bech32Encode(AddressHRP, a[:])
+}
+
 // String implements fmt.Stringer.
 func (a Address) String() string {
 	return a.Hex()
@@ -253,12 +344,13 @@ func (a Address) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(a[:]).MarshalText()
 }
 
-// UnmarshalText parses a hash in hex syntax.
+// UnmarshalText parses an address in hex syntax. Use ParseAddress where the
+// checksum needs to be validated or bech32 input is to be accepted.
 func (a *Address) UnmarshalText(input []byte) error {
 	return hexutil.UnmarshalFixedText("Address", input, a[:])
 }
 
-// UnmarshalJSON parses a hash in hex syntax.
+// UnmarshalJSON parses an address in hex syntax.
 func (a *Address) UnmarshalJSON(input []byte) error {
 	return hexutil.UnmarshalFixedJSON(addressT, input, a[:])
 }
@@ -20,6 +20,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"math/big"
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
@@ -371,3 +372,96 @@ func TestAddress_Value(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAddress(t *testing.T) {
+	addr := HexToAddress("0x00112233445566778899aabbccddeeff00112233445566778899")
+	checksummed := addr.Hex()
+
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{checksummed, nil},
+		{strings.ToLower(checksummed), nil},
+		{"0x" + strings.ToUpper(checksummed[2:]), nil},
+		{checksummed[2:], nil},
+		{addr.Bech32(), nil},
+		{strings.ToUpper(addr.Bech32()), nil},
+		{flipFirstLetter(checksummed), ErrAddressChecksum},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ErrEthereumAddress},
+		{"0x00112233", ErrAddressLength},
+		{checksummed + "00", ErrAddressLength},
+		{"0x00112233445566778899aabbccddeeff0011223344556677889g", ErrAddressSyntax},
+		{"test1" + addr.Bech32()[5:], ErrAddressSyntax},
+		{bech32Encode(AddressHRP, addr[5:]), ErrEthereumAddress},
+		{bech32Encode("luckx", addr[:]), ErrAddressSyntax},
+	}
+	for i, tt := range tests {
+		have, err := ParseAddress(tt.input)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("test %d: failed to parse %s: %v", i, tt.input, err)
+			} else if have != addr {
+				t.Errorf("test %d: address mismatch: have %x, want %x", i, have, addr)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err.Error()) {
+			t.Errorf("test %d: error mismatch for %s: have %v, want %v", i, tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestAddressBech32RoundTrip(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		var addr Address
+		rand.Read(addr[:])
+
+		text := addr.Bech32()
+		if !strings.HasPrefix(text, AddressHRP+"1") {
+			t.Fatalf("missing prefix: %s", text)
+		}
+		have, err := ParseAddress(text)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", text, err)
+		}
+		if have != addr {
+			t.Fatalf("round-trip mismatch: have %x, want %x", have, addr)
+		}
+	}
+}
+
+// Tests that decoding addresses doesn't validate the checksum, which is left to
+// ParseAddress at the boundaries accepting user input.
+func TestAddressUnmarshalNoChecksum(t *testing.T) {
+	addr := HexToAddress("0x00112233445566778899aabbccddeeff00112233445566778899")
+	broken := flipFirstLetter(addr.Hex())
+
+	var dec Address
+	if err := json.Unmarshal([]byte(`"`+broken+`"`), &dec); err != nil || dec != addr {
+		t.Errorf("json decoding mismatch: have %x/%v, want %x", dec, err, addr)
+	}
+	if err := dec.UnmarshalText([]byte(broken)); err != nil || dec != addr {
+		t.Errorf("text decoding mismatch: have %x/%v, want %x", dec, err, addr)
+	}
+	if err := dec.UnmarshalText([]byte(addr.Bech32())); err == nil {
+		t.Errorf("bech32 text decoded as hex")
+	}
+}
+
+// flipFirstLetter swaps the case of the first letter of a checksummed address,
+// breaking its checksum.
+func flipFirstLetter(hex string) string {
+	b := []byte(hex)
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 'a' && b[i] <= 'f' {
+			b[i] -= 'a' - 'A'
+			break
+		}
+		if b[i] >= 'A' && b[i] <= 'F' {
+			b[i] += 'a' - 'A'
+			break
+		}
+	}
+	return string(b)
+}
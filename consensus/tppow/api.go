@@ -0,0 +1,430 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/rlp"
+	"github.com/luck/go-luck/rpc"
+)
+
+// maxStatsRange is the maximum number of blocks a single range statistics
+// request may cover.
+const maxStatsRange = 10000
+
+// luckBuckets is the number of equally sized luck ranges the luck distribution
+// of the range statistics is reported in.
+const luckBuckets = 10
+
+var (
+	errTppowStopped  = errors.New("tppow stopped")
+	errBusy          = errors.New("busy, try again later")
+	errInvalidRange  = errors.New("invalid block range")
+	errRangeTooLarge = fmt.Errorf("block range exceeds %d blocks", maxStatsRange)
+)
+
+// API exposes Tppow related methods for the RPC interface.
+type API struct {
+	chain consensus.ChainReader
+	tppow *Tppow
+}
+
+// GetWork returns a first stage work package for external miner.
+//
+// The work package consists of 6 strings:
+//   result[0] - 32 bytes hex encoded current block header seal hash, identifying the work
+//   result[1] - 32 bytes hex encoded parent hash
+//   result[2] - 25 bytes hex encoded coinbase
+//   result[3] - hex encoded timestamp
+//   result[4] - hex encoded first stage boundary condition ("target"), DifficultyAlpha
+//   result[5] - hex encoded block number
+func (api *API) GetWork() ([6]string, error) {
+	if api.tppow.remote == nil {
+		return [6]string{}, errors.New("not supported")
+	}
+
+	var (
+		workCh = make(chan [6]string, 1)
+		errc   = make(chan error, 1)
+	)
+	select {
+	case api.tppow.remote.fetchWorkCh <- &sealWork{errc: errc, res: workCh}:
+	case <-api.tppow.remote.exitCh:
+		return [6]string{}, errTppowStopped
+	}
+	select {
+	case work := <-workCh:
+		return work, nil
+	case err := <-errc:
+		return [6]string{}, err
+	}
+}
+
+// SubmitLuck can be used by external miner to submit the first nonce satisfying
+// the first stage target. It returns the second stage work package derived from
+// the luck of that nonce.
+//
+// The second stage work package consists of 4 strings:
+//   result[0] - 32 bytes hex encoded block header seal hash, identifying the work
+//   result[1] - hex encoded luck derived from the first nonce
+//   result[2] - hex encoded second stage boundary condition ("target"), DifficultyBeta
+//   result[3] - hex encoded RLP of the header to search the second nonce for
+func (api *API) SubmitLuck(firstNonce types.BlockNonce, hash common.Hash) ([4]string, error) {
+	if api.tppow.remote == nil {
+		return [4]string{}, errors.New("not supported")
+	}
+
+	var (
+		workCh = make(chan [4]string, 1)
+		errc   = make(chan error, 1)
+	)
+	select {
+	case api.tppow.remote.submitLuckCh <- &luckResult{firstNonce: firstNonce, hash: hash, errc: errc, res: workCh}:
+	case <-api.tppow.remote.exitCh:
+		return [4]string{}, errTppowStopped
+	}
+	select {
+	case work := <-workCh:
+		return work, nil
+	case err := <-errc:
+		return [4]string{}, err
+	}
+}
+
+// SubmitWork can be used by external miner to submit their POW solution.
+// It returns an indication if the work was accepted.
+// Note either an invalid solution, a stale work a non-existent work will return false.
+func (api *API) SubmitWork(firstNonce types.BlockNonce, secondNonce types.BlockNonce, hash common.Hash) bool {
+	if api.tppow.remote == nil {
+		return false
+	}
+
+	var errc = make(chan error, 1)
+	select {
+	case api.tppow.remote.submitWorkCh <- &mineResult{
+		firstNonce:  firstNonce,
+		secondNonce: secondNonce,
+		hash:        hash,
+		errc:        errc,
+	}:
+	case <-api.tppow.remote.exitCh:
+		return false
+	}
+	err := <-errc
+	return err == nil
+}
+
+// SubmitHashRate can be used for remote miners to submit their hash rate.
+// This enables the node to report the combined hash rate of all miners
+// which submit work through this node.
+//
+// It accepts the miner hash rate and an identifier which must be unique
+// between nodes.
+func (api *API) SubmitHashRate(rate hexutil.Uint64, id common.Hash) bool {
+	if api.tppow.remote == nil {
+		return false
+	}
+
+	var done = make(chan struct{}, 1)
+	select {
+	case api.tppow.remote.submitRateCh <- &hashrate{done: done, rate: uint64(rate), id: id}:
+	case <-api.tppow.remote.exitCh:
+		return false
+	}
+
+	// Block until hash rate submitted successfully.
+	<-done
+	return true
+}
+
+// GetHashrate returns the current hashrate for local CPU miner and remote miner,
+// summed over both search stages.
+func (api *API) GetHashrate() uint64 {
+	return uint64(api.tppow.Hashrate())
+}
+
+// GetLuckHashrate returns the current first stage (luck search) hashrate of the
+// local miner.
+func (api *API) GetLuckHashrate() uint64 {
+	return uint64(api.tppow.LuckHashrate())
+}
+
+// GetBlockHashrate returns the current second stage (block search) hashrate of
+// the local miner.
+func (api *API) GetBlockHashrate() uint64 {
+	return uint64(api.tppow.BlockHashrate())
+}
+
+// LuckInfo contains the two-stage proof-of-work parameters of a block.
+type LuckInfo struct {
+	Number          hexutil.Uint64 `json:"number"`
+	Hash            common.Hash    `json:"hash"`
+	Lucky           *hexutil.Big   `json:"lucky"`
+	Basis           *hexutil.Big   `json:"basis"`
+	DifficultyAlpha *hexutil.Big   `json:"difficultyAlpha"`
+	DifficultyBeta  *hexutil.Big   `json:"difficultyBeta"`
+	Difficulty      *hexutil.Big   `json:"difficulty"`
+}
+
+// GetLuck retrieves the luck and difficulty parameters of the given block.
+func (api *API) GetLuck(number *rpc.BlockNumber) (*LuckInfo, error) {
+	header := api.header(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return &LuckInfo{
+		Number:          hexutil.Uint64(header.Number.Uint64()),
+		Hash:            header.Hash(),
+		Lucky:           (*hexutil.Big)(header.Lucky),
+		Basis:           (*hexutil.Big)(header.Basis),
+		DifficultyAlpha: (*hexutil.Big)(header.DifficultyAlpha),
+		DifficultyBeta:  (*hexutil.Big)(header.DifficultyBeta),
+		Difficulty:      (*hexutil.Big)(header.Difficulty),
+	}, nil
+}
+
+// SealStage is the outcome of a single check of the two-stage seal verification.
+// Have is omitted for hash stages that passed, as only a hash missing its target
+// is reported back by the verifier.
+type SealStage struct {
+	Stage  string       `json:"stage"`
+	Passed bool         `json:"passed"`
+	Have   *hexutil.Big `json:"have,omitempty"`
+	Want   *hexutil.Big `json:"want"`
+}
+
+// SealVerification is the detailed outcome of verifying the seal of a header.
+type SealVerification struct {
+	Valid  bool        `json:"valid"`
+	Reason string      `json:"reason,omitempty"`
+	Stages []SealStage `json:"stages"`
+}
+
+// sealStages names the checks of verifySeal in the order they run, along with
+// the header field a passing check is reported against.
+var sealStages = []struct {
+	name  string
+	err   error
+	field func(header *types.Header) *big.Int
+	hash  bool
+}{
+	{"luck", ErrAlphaTarget, func(h *types.Header) *big.Int { return h.DifficultyAlpha }, true},
+	{"lucky", ErrLuckMismatch, func(h *types.Header) *big.Int { return h.Lucky }, false},
+	{"beta", ErrBetaMismatch, func(h *types.Header) *big.Int { return h.DifficultyBeta }, false},
+	{"block", ErrBetaTarget, func(h *types.Header) *big.Int { return h.DifficultyBeta }, true},
+	{"difficulty", ErrDifficultyMismatch, func(h *types.Header) *big.Int { return h.Difficulty }, false},
+}
+
+// VerifySeal checks the two-stage seal of an RLP encoded header, reporting the
+// outcome of each stage in the order the consensus engine checks them and
+// stopping at the first failure:
+//   luck       - first nonce hash is below DifficultyAlpha
+//   lucky      - the header's luck matches the one derived from the first nonce
+//   beta       - DifficultyBeta matches the one derived from the luck and basis
+//   block      - second nonce hash is below DifficultyBeta
+//   difficulty - the header's difficulty matches the one derived from its fields
+//
+// Seals are checked against the cache of recently verified seals of the engine,
+// but hashed in a slot of their own so that RPC callers can't hold up block
+// import. Requests arriving while that slot is taken are rejected.
+func (api *API) VerifySeal(blob hexutil.Bytes) (*SealVerification, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return nil, err
+	}
+	if header.Lucky.Cmp(api.tppow.config.MaxLuck) >= 0 {
+		return nil, fmt.Errorf("luck %v exceeds maximum %v", header.Lucky, api.tppow.config.MaxLuck)
+	}
+	hash := header.Hash()
+	if !api.tppow.seals.Contains(hash) {
+		select {
+		case api.tppow.apiVerifiers <- struct{}{}:
+		default:
+			return nil, errBusy
+		}
+		err := api.tppow.verifySeal(header)
+		<-api.tppow.apiVerifiers
+
+		if err != nil {
+			serr, ok := err.(*SealError)
+			if !ok {
+				return nil, err
+			}
+			return newSealVerification(header, serr), nil
+		}
+		api.tppow.seals.Add(hash, struct{}{})
+	}
+	return newSealVerification(header, nil), nil
+}
+
+// newSealVerification builds the stage report of a header from the outcome of
+// verifySeal, marking the stages before the failed check as passed.
+func newSealVerification(header *types.Header, serr *SealError) *SealVerification {
+	res := &SealVerification{Valid: serr == nil}
+	for _, stage := range sealStages {
+		if serr != nil && serr.Err == stage.err {
+			res.Stages = append(res.Stages, SealStage{stage.name, false, (*hexutil.Big)(serr.Have), (*hexutil.Big)(serr.Want)})
+			res.Reason = fmt.Sprintf("%s check failed: %v", stage.name, serr)
+			break
+		}
+		want := stage.field(header)
+		have := want
+		if stage.hash {
+			have = nil
+		}
+		res.Stages = append(res.Stages, SealStage{stage.name, true, (*hexutil.Big)(have), (*hexutil.Big)(want)})
+	}
+	return res
+}
+
+// NextParams contains the difficulty parameters the next block would carry.
+type NextParams struct {
+	Number          hexutil.Uint64 `json:"number"`
+	Time            hexutil.Uint64 `json:"time"`
+	Basis           *hexutil.Big   `json:"basis"`
+	DifficultyAlpha *hexutil.Big   `json:"difficultyAlpha"`
+}
+
+// EstimateNextParams calculates the basis and first stage difficulty of a block
+// built on top of the current head, assuming it is sealed right now.
+func (api *API) EstimateNextParams() (*NextParams, error) {
+	parent := api.chain.CurrentHeader()
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	timestamp := uint64(time.Now().Unix())
+	if timestamp <= parent.Time {
+		timestamp = parent.Time + 1
+	}
+	number := parent.Number.Uint64() + 1
+	basis, alpha := api.tppow.calcParam(api.chain, number, timestamp, parent, nil)
+
+	return &NextParams{
+		Number:          hexutil.Uint64(number),
+		Time:            hexutil.Uint64(timestamp),
+		Basis:           (*hexutil.Big)(basis),
+		DifficultyAlpha: (*hexutil.Big)(alpha),
+	}, nil
+}
+
+// CalcBeta calculates the second stage difficulty a given luck results in. If
+// no basis is specified, the one estimated for the next block is used.
+func (api *API) CalcBeta(luck hexutil.Big, basis *hexutil.Big) (*hexutil.Big, error) {
+	if luck.ToInt().Sign() < 0 || luck.ToInt().Cmp(api.tppow.config.MaxLuck) >= 0 {
+		return nil, fmt.Errorf("luck %v out of range [0, %v)", luck.ToInt(), api.tppow.config.MaxLuck)
+	}
+	if basis == nil {
+		next, err := api.EstimateNextParams()
+		if err != nil {
+			return nil, err
+		}
+		basis = next.Basis
+	}
+	return (*hexutil.Big)(api.tppow.calcBeta(luck.ToInt(), basis.ToInt())), nil
+}
+
+// RangeStats contains statistics over the seals of a range of blocks.
+type RangeStats struct {
+	From             hexutil.Uint64 `json:"from"`
+	To               hexutil.Uint64 `json:"to"`
+	AverageBlockTime float64        `json:"averageBlockTime"`
+	MinLuck          *hexutil.Big   `json:"minLuck"`
+	MaxLuck          *hexutil.Big   `json:"maxLuck"`
+	AverageLuck      *hexutil.Big   `json:"averageLuck"`
+	LuckDistribution []uint64       `json:"luckDistribution"` // Number of blocks per tenth of the luck range
+}
+
+// GetRangeStats gathers block time and luck statistics over the inclusive range
+// of blocks [from, to].
+func (api *API) GetRangeStats(from rpc.BlockNumber, to rpc.BlockNumber) (*RangeStats, error) {
+	first, last := api.header(&from), api.header(&to)
+	if first == nil || last == nil {
+		return nil, errUnknownBlock
+	}
+	start, end := first.Number.Uint64(), last.Number.Uint64()
+	if start > end {
+		return nil, errInvalidRange
+	}
+	if end-start >= maxStatsRange {
+		return nil, errRangeTooLarge
+	}
+	var (
+		stats = &RangeStats{
+			From:             hexutil.Uint64(start),
+			To:               hexutil.Uint64(end),
+			LuckDistribution: make([]uint64, luckBuckets),
+		}
+		minLuck, maxLuck *big.Int
+		sumLuck          = new(big.Int)
+		bucket           = new(big.Int).Div(api.tppow.config.MaxLuck, big.NewInt(luckBuckets))
+	)
+	for number := start; number <= end; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		luck := header.Lucky
+		if luck == nil {
+			luck = new(big.Int)
+		}
+		if minLuck == nil || luck.Cmp(minLuck) < 0 {
+			minLuck = luck
+		}
+		if maxLuck == nil || luck.Cmp(maxLuck) > 0 {
+			maxLuck = luck
+		}
+		sumLuck.Add(sumLuck, luck)
+
+		idx := luckBuckets - 1
+		if bucket.Sign() > 0 {
+			if i := new(big.Int).Div(luck, bucket); i.IsUint64() && i.Uint64() < luckBuckets {
+				idx = int(i.Uint64())
+			}
+		}
+		stats.LuckDistribution[idx]++
+	}
+	count := end - start + 1
+	stats.MinLuck, stats.MaxLuck = (*hexutil.Big)(minLuck), (*hexutil.Big)(maxLuck)
+	stats.AverageLuck = (*hexutil.Big)(sumLuck.Div(sumLuck, new(big.Int).SetUint64(count)))
+
+	// Block times are measured against each block's parent, if there is one
+	if start > 0 {
+		if parent := api.chain.GetHeaderByNumber(start - 1); parent != nil {
+			stats.AverageBlockTime = float64(last.Time-parent.Time) / float64(count)
+		}
+	} else if count > 1 {
+		stats.AverageBlockTime = float64(last.Time-first.Time) / float64(count-1)
+	}
+	return stats, nil
+}
+
+// header retrieves the header for the given block number, defaulting to the
+// current head if none or the latest is requested.
+func (api *API) header(number *rpc.BlockNumber) *types.Header {
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		return api.chain.CurrentHeader()
+	}
+	return api.chain.GetHeaderByNumber(uint64(number.Int64()))
+}
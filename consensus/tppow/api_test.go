@@ -0,0 +1,232 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/core"
+	"github.com/luck/go-luck/core/rawdb"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/core/vm"
+	"github.com/luck/go-luck/params"
+	"github.com/luck/go-luck/rlp"
+	"github.com/luck/go-luck/rpc"
+)
+
+// newTestAPI creates a tppow API backed by a chain of n fake sealed blocks.
+func newTestAPI(t *testing.T, n int) (*API, *core.BlockChain) {
+	engine := NewFaker()
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, n, nil)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	return &API{chain: chain, tppow: engine}, chain
+}
+
+func TestAPIGetLuck(t *testing.T) {
+	api, chain := newTestAPI(t, 3)
+	defer chain.Stop()
+
+	number := rpc.BlockNumber(2)
+	info, err := api.GetLuck(&number)
+	if err != nil {
+		t.Fatalf("failed to retrieve luck: %v", err)
+	}
+	header := chain.GetHeaderByNumber(2)
+	if info.Hash != header.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", info.Hash, header.Hash())
+	}
+	if info.DifficultyAlpha.ToInt().Cmp(header.DifficultyAlpha) != 0 {
+		t.Errorf("alpha mismatch: have %v, want %v", info.DifficultyAlpha, header.DifficultyAlpha)
+	}
+	if info, err := api.GetLuck(nil); err != nil || uint64(info.Number) != 3 {
+		t.Errorf("head luck mismatch: have %v/%v, want block 3", info, err)
+	}
+	missing := rpc.BlockNumber(10)
+	if _, err := api.GetLuck(&missing); err != errUnknownBlock {
+		t.Errorf("missing block error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+}
+
+func TestAPIVerifySeal(t *testing.T) {
+	api, chain := newTestAPI(t, 1)
+	defer chain.Stop()
+
+	blob, _ := rlp.EncodeToBytes(chain.CurrentHeader())
+	res, err := api.VerifySeal(blob)
+	if err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	if res.Valid {
+		t.Errorf("unsealed header reported valid")
+	}
+	if len(res.Stages) == 0 || len(res.Stages) > 5 {
+		t.Fatalf("stage count mismatch: have %d, want 1-5", len(res.Stages))
+	}
+	// Verification stops at the first failing stage
+	for i, stage := range res.Stages {
+		if last := i == len(res.Stages)-1; stage.Passed == last {
+			t.Errorf("stage %d (%s) passed mismatch: have %v, want %v", i, stage.Stage, stage.Passed, !last)
+		}
+	}
+	if res.Reason == "" {
+		t.Errorf("missing failure reason")
+	}
+}
+
+// Tests that a header with a zero basis past the difficulty adjustment is
+// reported as a failed stage instead of crashing the difficulty calculation.
+func TestAPIVerifySealZeroBasis(t *testing.T) {
+	tppow := NewFaker()
+	tppow.mode = ModeNormal
+	api := &API{tppow: tppow}
+
+	header := &types.Header{
+		Number:          new(big.Int).Set(tppow.config.DifficultyAdjustBlock),
+		Basis:           new(big.Int),
+		DifficultyAlpha: new(big.Int).Set(max256),
+		DifficultyBeta:  new(big.Int),
+		Difficulty:      new(big.Int),
+	}
+	header.Lucky = tppow.calcLuck(header, 0)
+	blob, _ := rlp.EncodeToBytes(header)
+
+	res, err := api.VerifySeal(blob)
+	if err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	if res.Valid {
+		t.Fatalf("zero basis seal reported valid")
+	}
+	if stage := res.Stages[len(res.Stages)-1]; stage.Stage != "block" || stage.Passed {
+		t.Errorf("failing stage mismatch: have %s (passed %v), want block", stage.Stage, stage.Passed)
+	}
+}
+
+// Tests that uncached seals are verified in a slot of the API, rejecting callers
+// while it is taken instead of queueing them behind the consensus verifiers.
+func TestAPIVerifySealBusy(t *testing.T) {
+	tppow := NewFaker()
+	tppow.mode = ModeNormal
+	api := &API{tppow: tppow}
+
+	for i := 0; i < cap(tppow.apiVerifiers); i++ {
+		tppow.apiVerifiers <- struct{}{}
+	}
+	blob, _ := rlp.EncodeToBytes(&types.Header{Number: big.NewInt(1), Lucky: new(big.Int)})
+	if _, err := api.VerifySeal(blob); err != errBusy {
+		t.Errorf("error mismatch: have %v, want %v", err, errBusy)
+	}
+}
+
+// Tests that seals verified through the API populate and reuse the seal cache of
+// the engine, skipping the Argon2 hashes on a hit.
+func TestAPIVerifySealCache(t *testing.T) {
+	tppow := NewFaker()
+	tppow.mode = ModeNormal
+	api := &API{tppow: tppow}
+
+	header := &types.Header{
+		Number:          big.NewInt(1),
+		Basis:           new(big.Int).Lsh(common.Big1, 200),
+		DifficultyAlpha: new(big.Int).Set(max256),
+	}
+	header.Lucky = tppow.calcLuck(header, 0)
+	header.DifficultyBeta = tppow.calcBeta(header.Lucky, header.Basis)
+	header.Difficulty = tppow.calcDifficulty(header)
+	blob, _ := rlp.EncodeToBytes(header)
+
+	if res, err := api.VerifySeal(blob); err != nil || !res.Valid {
+		t.Fatalf("failed to verify seal: %v %v", res, err)
+	}
+	if !tppow.seals.Contains(header.Hash()) {
+		t.Fatalf("verified seal not cached")
+	}
+	// Cache hits must not need a verifier slot
+	for i := 0; i < cap(tppow.apiVerifiers); i++ {
+		tppow.apiVerifiers <- struct{}{}
+	}
+	res, err := api.VerifySeal(blob)
+	if err != nil || !res.Valid {
+		t.Fatalf("cached seal rejected: %v %v", res, err)
+	}
+	if res.Stages[0].Have != nil || res.Stages[3].Have != nil {
+		t.Errorf("cached seal hashed again")
+	}
+}
+
+func TestAPICalcBeta(t *testing.T) {
+	api, chain := newTestAPI(t, 1)
+	defer chain.Stop()
+
+	basis := big.NewInt(1000)
+	beta, err := api.CalcBeta(hexutil.Big(*big.NewInt(0)), (*hexutil.Big)(basis))
+	if err != nil {
+		t.Fatalf("failed to calculate beta: %v", err)
+	}
+	if beta.ToInt().Cmp(basis) != 0 {
+		t.Errorf("zero luck beta mismatch: have %v, want %v", beta, basis)
+	}
+	if _, err := api.CalcBeta(hexutil.Big(*params.DefaultTppowConfig.MaxLuck), (*hexutil.Big)(basis)); err == nil {
+		t.Errorf("out of range luck accepted")
+	}
+	next, err := api.EstimateNextParams()
+	if err != nil {
+		t.Fatalf("failed to estimate next params: %v", err)
+	}
+	if uint64(next.Number) != 2 {
+		t.Errorf("next number mismatch: have %d, want %d", next.Number, 2)
+	}
+	if beta, err := api.CalcBeta(hexutil.Big(*big.NewInt(0)), nil); err != nil || beta.ToInt().Cmp(next.Basis.ToInt()) != 0 {
+		t.Errorf("estimated basis beta mismatch: have %v/%v, want %v", beta, err, next.Basis)
+	}
+}
+
+func TestAPIGetRangeStats(t *testing.T) {
+	api, chain := newTestAPI(t, 5)
+	defer chain.Stop()
+
+	stats, err := api.GetRangeStats(1, 5)
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	// Generated blocks are spaced 10 seconds apart
+	if stats.AverageBlockTime != 10 {
+		t.Errorf("block time mismatch: have %v, want %v", stats.AverageBlockTime, 10)
+	}
+	var total uint64
+	for _, n := range stats.LuckDistribution {
+		total += n
+	}
+	if total != 5 {
+		t.Errorf("luck distribution size mismatch: have %d, want %d", total, 5)
+	}
+	if _, err := api.GetRangeStats(5, 1); err != errInvalidRange {
+		t.Errorf("inverted range error mismatch: have %v, want %v", err, errInvalidRange)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	crand "crypto/rand"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/params"
+)
+
+// BenchmarkResult holds the hashrates of the seal stages measured by Benchmark.
+type BenchmarkResult struct {
+	Threads   int     // Number of threads every stage was run on
+	Memory    uint64  // Argon2 memory needed by a single hash, in bytes
+	LuckRate  float64 // First stage (SealLuck) hashes per second, across all threads
+	CalcRate  float64 // Luck derivations (calcLuck) per second, across all threads
+	BlockRate float64 // Second stage (SealBlock) hashes per second, across all threads
+
+	config *params.TppowConfig
+}
+
+// Benchmark measures the hashrate of every seal stage over synthetic headers at
+// the given height, running each stage on the given number of threads for the
+// given duration. If threads is not positive, all CPUs are used.
+func Benchmark(config *params.TppowConfig, number *big.Int, threads int, duration time.Duration) *BenchmarkResult {
+	d := &Tppow{config: config.WithDefaults()}
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	return &BenchmarkResult{
+		Threads:   threads,
+		Memory:    uint64(d.argon2Memory(number)) * 1024,
+		LuckRate:  benchStage(number, threads, duration, func(h *types.Header, n uint64) { d.SealLuck(h, n) }),
+		CalcRate:  benchStage(number, threads, duration, func(h *types.Header, n uint64) { d.calcLuck(h, n) }),
+		BlockRate: benchStage(number, threads, duration, func(h *types.Header, n uint64) { d.SealBlock(h, n) }),
+		config:    d.config,
+	}
+}
+
+// benchStage runs a seal stage on the given number of threads for the given
+// duration, returning the number of hashes done per second.
+func benchStage(number *big.Int, threads int, duration time.Duration, hash func(*types.Header, uint64)) float64 {
+	var (
+		pend     sync.WaitGroup
+		total    uint64
+		start    = time.Now()
+		deadline = start.Add(duration)
+	)
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+
+			header := benchHeader(number)
+			nonce := uint64(0)
+			for ; time.Now().Before(deadline); nonce++ {
+				hash(header, nonce)
+			}
+			atomic.AddUint64(&total, nonce)
+		}()
+	}
+	pend.Wait()
+	return float64(total) / time.Since(start).Seconds()
+}
+
+// benchHeader creates a synthetic header at the given height with a random
+// parent, all fields the seal stages hash filled in.
+func benchHeader(number *big.Int) *types.Header {
+	header := &types.Header{
+		UncleHash:       types.EmptyUncleHash,
+		TxHash:          types.EmptyRootHash,
+		ReceiptHash:     types.EmptyRootHash,
+		Number:          new(big.Int).Set(number),
+		GasLimit:        params.GenesisGasLimit,
+		Time:            uint64(time.Now().Unix()),
+		Basis:           new(big.Int).Set(initBasis),
+		Lucky:           new(big.Int),
+		DifficultyAlpha: new(big.Int).Set(initDifficultyAlpha),
+		DifficultyBeta:  new(big.Int).Set(initBasis),
+	}
+	crand.Read(header.ParentHash[:])
+	crand.Read(header.Coinbase[:])
+	return header
+}
+
+// ExpectedSealTime estimates the average time needed to seal a block with the
+// given difficulty parameters, mining with the benchmarked hashrates alone.
+//
+// A first stage hash succeeds with probability alpha*HashScale/2^256. The found
+// luck L, uniform below MaxLuck, then sets the second stage target to
+// basis*(MaxLuck/(MaxLuck-L))^2, so a second stage hash succeeds with probability
+// basis*HashScale/2^256 scaled by the inverse of ((MaxLuck-L)/MaxLuck)^2, which
+// is about 1/3 on average.
+func (r *BenchmarkResult) ExpectedSealTime(alpha, basis *big.Int) time.Duration {
+	space := new(big.Float).SetInt(new(big.Int).Lsh(common.Big1, 256))
+	attempts := func(target *big.Int) float64 {
+		if target.Sign() <= 0 {
+			return math.Inf(1)
+		}
+		scaled := new(big.Float).SetInt(new(big.Int).Mul(target, r.config.HashScale))
+		n, _ := new(big.Float).Quo(space, scaled).Float64()
+		return math.Max(n, 1)
+	}
+	maxLuck, _ := new(big.Float).SetInt(r.config.MaxLuck).Float64()
+	scale := (maxLuck + 1) * (2*maxLuck + 1) / (6 * maxLuck * maxLuck)
+
+	seconds := attempts(alpha)/r.LuckRate + float64(r.Threads)/r.CalcRate + math.Max(attempts(basis)*scale, 1)/r.BlockRate
+	if math.IsNaN(seconds) || seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
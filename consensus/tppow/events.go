@@ -0,0 +1,150 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/event"
+	"github.com/luck/go-luck/metrics"
+)
+
+// sealEventQueue is the number of sealing events queued for delivery to the
+// subscribers, any further ones are dropped until the queue drains.
+const sealEventQueue = 64
+
+var sealEventDroppedMeter = metrics.NewRegisteredMeter("tppow/events/dropped", nil)
+
+// LuckFoundEvent is posted when a local miner thread finds a first nonce which
+// satisfies the DifficultyAlpha of the block being sealed.
+type LuckFoundEvent struct {
+	Miner          int         // Index of the miner thread which found the nonce
+	Number         uint64      // Number of the block being sealed
+	SealHash       common.Hash // Seal hash of the block being sealed
+	FirstNonce     types.BlockNonce
+	Lucky          *big.Int // Luck derived from the first nonce
+	DifficultyBeta *big.Int // Second stage boundary derived from the luck
+}
+
+// NonceFoundEvent is posted when a local miner thread finds a second nonce which
+// satisfies the DifficultyBeta of the block being sealed, completing its seal.
+type NonceFoundEvent struct {
+	Miner       int // Index of the miner thread which found the nonce
+	Block       *types.Block
+	SealHash    common.Hash
+	FirstNonce  types.BlockNonce
+	SecondNonce types.BlockNonce
+	Lucky       *big.Int
+}
+
+// SealDiscardedEvent is posted when a sealed block is dropped because it got
+// superseded by new work before the miner read it from the result channel.
+type SealDiscardedEvent struct {
+	Mode     string // Sealing mode which produced the block: local, remote or fake
+	Block    *types.Block
+	SealHash common.Hash
+}
+
+// sealFeeds are the feeds the sealing events are delivered through. Events are
+// posted from the miner threads and the remote sealer, which must never wait
+// for slow subscribers, so they are queued and delivered by a background loop.
+type sealFeeds struct {
+	luckFound     event.Feed
+	nonceFound    event.Feed
+	sealDiscarded event.Feed
+	scope         event.SubscriptionScope
+
+	queue  chan interface{} // Events waiting for delivery, created on first use
+	quit   chan struct{}    // Quit channel to terminate the delivery loop
+	closed bool             // Whether the feeds were closed, dropping new events
+	lock   sync.Mutex       // Protects the delivery loop lifecycle
+}
+
+// post queues a sealing event for delivery without blocking, dropping it if the
+// subscribers fell too far behind.
+func (f *sealFeeds) post(ev interface{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+	if f.queue == nil {
+		f.queue, f.quit = make(chan interface{}, sealEventQueue), make(chan struct{})
+		go f.loop(f.queue, f.quit)
+	}
+	select {
+	case f.queue <- ev:
+	default:
+		sealEventDroppedMeter.Mark(1)
+	}
+}
+
+// loop delivers the queued sealing events to the subscribers of their feeds.
+func (f *sealFeeds) loop(queue chan interface{}, quit chan struct{}) {
+	for {
+		select {
+		case ev := <-queue:
+			switch ev := ev.(type) {
+			case LuckFoundEvent:
+				f.luckFound.Send(ev)
+			case NonceFoundEvent:
+				f.nonceFound.Send(ev)
+			case SealDiscardedEvent:
+				f.sealDiscarded.Send(ev)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// close terminates the delivery loop and all subscriptions, unblocking any
+// delivery in progress.
+func (f *sealFeeds) close() {
+	f.lock.Lock()
+	if !f.closed {
+		f.closed = true
+		if f.quit != nil {
+			close(f.quit)
+		}
+	}
+	f.lock.Unlock()
+
+	f.scope.Close()
+}
+
+// SubscribeLuckFound registers a subscription for first nonces found by the
+// local miner threads.
+func (d *Tppow) SubscribeLuckFound(ch chan<- LuckFoundEvent) event.Subscription {
+	return d.feeds.scope.Track(d.feeds.luckFound.Subscribe(ch))
+}
+
+// SubscribeNonceFound registers a subscription for blocks sealed by the local
+// miner threads.
+func (d *Tppow) SubscribeNonceFound(ch chan<- NonceFoundEvent) event.Subscription {
+	return d.feeds.scope.Track(d.feeds.nonceFound.Subscribe(ch))
+}
+
+// SubscribeSealDiscarded registers a subscription for sealed blocks which were
+// dropped because new work superseded them before the miner read them.
+func (d *Tppow) SubscribeSealDiscarded(ch chan<- SealDiscardedEvent) event.Subscription {
+	return d.feeds.scope.Track(d.feeds.sealDiscarded.Subscribe(ch))
+}
@@ -0,0 +1,702 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/log"
+	"github.com/luck/go-luck/metrics"
+	"github.com/luck/go-luck/rlp"
+)
+
+const (
+	// staleThreshold is the maximum depth of the acceptable stale but valid tppow solution.
+	staleThreshold = 7
+
+	// maxRemoteVerifiers is the maximum number of remote submissions verified
+	// concurrently. Submissions arriving while all are in use are rejected.
+	maxRemoteVerifiers = 2
+)
+
+var (
+	errNoMiningWork      = errors.New("no mining work available yet")
+	errInvalidSealResult = errors.New("invalid or stale proof-of-work solution")
+)
+
+var (
+	sealDeliveredMeter  = metrics.NewRegisteredMeter("tppow/seal/delivered", nil)
+	sealSupersededMeter = metrics.NewRegisteredMeter("tppow/seal/superseded", nil)
+)
+
+// Seal implements consensus.Engine, attempting to find a first nonce that
+// satisfies DifficultyAlpha and a second nonce that satisfies the luck derived
+// DifficultyBeta. The search is spread over the configured number of threads,
+// each of them working on a disjoint slice of both nonce spaces.
+func (d *Tppow) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	// If we're running a fake PoW, simply return a zero nonce pair immediately
+	if d.mode == ModeFake || d.mode == ModeFullFake {
+		header := block.Header()
+		header.FirstNonce, header.SecondNonce = types.BlockNonce{}, types.BlockNonce{}
+		header.Lucky = new(big.Int)
+		if header.Basis != nil {
+			header.DifficultyBeta = d.calcBeta(header.Lucky, header.Basis)
+		}
+		go d.deliverSeal("fake", block.WithSeal(header), results, stop)
+		return nil
+	}
+	// Create a runner and the multiple search threads it directs
+	abort := make(chan struct{})
+
+	d.lock.Lock()
+	threads := d.threads
+	if d.rand == nil {
+		seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
+		if err != nil {
+			d.lock.Unlock()
+			return err
+		}
+		d.rand = rand.New(rand.NewSource(seed.Int64()))
+	}
+	firstSeed, secondSeed := uint64(d.rand.Int63()), uint64(d.rand.Int63())
+	d.lock.Unlock()
+
+	if threads == 0 {
+		threads = runtime.NumCPU()
+	}
+	if threads < 0 {
+		threads = 0 // Allows disabling local mining without extra logic around local/remote
+	}
+	// Push new work to remote sealer
+	if d.remote != nil {
+		d.remote.workCh <- &sealTask{block: block, results: results, stop: stop}
+	}
+	var (
+		pend   sync.WaitGroup
+		locals = make(chan *types.Block)
+	)
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func(id int, first, second uint64) {
+			defer pend.Done()
+			d.mine(block, id, first, second, abort, locals)
+		}(i, firstSeed+nonceOffset(i, threads), secondSeed+nonceOffset(i, threads))
+	}
+	// Wait until sealing is terminated or a nonce pair is found
+	go func() {
+		var result *types.Block
+		select {
+		case <-stop:
+			// Outside abort, stop all miner threads
+			close(abort)
+		case result = <-locals:
+			// One of the threads found a block, abort all others and hand it over
+			close(abort)
+			d.deliverSeal("local", result, results, stop)
+		case <-d.update:
+			// Thread count was changed on user request, restart
+			close(abort)
+			if err := d.Seal(chain, block, results, stop); err != nil {
+				log.Error("Failed to restart sealing after update", "err", err)
+			}
+		}
+		// Wait for all miners to terminate and return the block
+		pend.Wait()
+	}()
+	return nil
+}
+
+// deliverSeal hands a sealed block over to the miner. As found blocks embody
+// real work, it waits for the result channel to be read instead of dropping the
+// block, unless the sealing task gets superseded by new work first.
+func (d *Tppow) deliverSeal(mode string, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) bool {
+	select {
+	case results <- block:
+		sealDeliveredMeter.Mark(1)
+		return true
+	case <-stop:
+	}
+	// The miner moved on to new work, which might be a mere recommit with more
+	// transactions on the same parent, so still hand the block over if it can be
+	// taken right away.
+	select {
+	case results <- block:
+		sealDeliveredMeter.Mark(1)
+		return true
+	default:
+	}
+	sealhash := d.SealHash(block.Header())
+	log.Warn("Sealed block superseded by new work", "mode", mode, "number", block.Number(), "sealhash", sealhash)
+	sealSupersededMeter.Mark(1)
+	d.feeds.post(SealDiscardedEvent{Mode: mode, Block: block, SealHash: sealhash})
+	return false
+}
+
+// luckKey identifies the inputs the first sealing stage of a header depends on.
+// Headers differing in their transactions, state or extra data only share it.
+type luckKey struct {
+	parent   common.Hash
+	coinbase common.Address
+	time     uint64
+}
+
+// luckSolution is a first nonce found for a luckKey, along with the target it
+// was found for and the luck it results in.
+type luckSolution struct {
+	firstNonce uint64
+	alpha      *big.Int
+	lucky      *big.Int
+}
+
+// fillCachedLuck fills in the luck dependent header fields from a previously
+// found first nonce satisfying the first stage of the header, if any. Recommits
+// of the same work with a different set of transactions can thus skip right to
+// the second stage.
+func (d *Tppow) fillCachedLuck(header *types.Header) bool {
+	cached, ok := d.lucks.Get(luckKey{header.ParentHash, header.Coinbase, header.Time})
+	if !ok {
+		return false
+	}
+	sol := cached.(*luckSolution)
+	if header.DifficultyAlpha == nil || sol.alpha.Cmp(header.DifficultyAlpha) != 0 {
+		return false
+	}
+	d.applyLuck(header, sol.firstNonce, new(big.Int).Set(sol.lucky))
+	return true
+}
+
+// cacheLuck stores the first stage solution filled into the given header.
+func (d *Tppow) cacheLuck(header *types.Header) {
+	key := luckKey{header.ParentHash, header.Coinbase, header.Time}
+	sol := &luckSolution{
+		firstNonce: header.FirstNonce.Uint64(),
+		alpha:      new(big.Int).Set(header.DifficultyAlpha),
+		lucky:      new(big.Int).Set(header.Lucky),
+	}
+	d.lucks.ContainsOrAdd(key, sol)
+}
+
+// nonceOffset returns the start of the id-th of threads equally sized slices of
+// the 64 bit nonce space, relative to a random seed. Keeping the slices apart
+// ensures that no two threads ever evaluate the same nonce for a work package.
+func nonceOffset(id int, threads int) uint64 {
+	return uint64(id) * (math.MaxUint64 / uint64(threads))
+}
+
+// mine is the actual two-stage proof-of-work miner. It first searches for a
+// first nonce starting from firstSeed that satisfies DifficultyAlpha, derives
+// the block luck and DifficultyBeta from it, and then searches for a second
+// nonce starting from secondSeed that satisfies DifficultyBeta. The first stage
+// is skipped if a solution was already found for the same parent, coinbase and
+// timestamp, as is the case when the miner merely recommits new transactions.
+func (d *Tppow) mine(block *types.Block, id int, firstSeed uint64, secondSeed uint64, abort chan struct{}, found chan *types.Block) {
+	var (
+		header     = block.Header()
+		sealhash   = d.SealHash(header)
+		firstNonce = firstSeed
+		logger     = log.New("miner", id, "number", header.Number, "sealhash", sealhash)
+	)
+	logger.Trace("Started tppow search for new nonces", "firstSeed", firstSeed, "secondSeed", secondSeed)
+
+	// Recommits only change the transactions, resume with the known first nonce
+	if d.fillCachedLuck(header) {
+		logger.Trace("Reusing cached tppow first nonce", "firstNonce", header.FirstNonce.Uint64())
+		d.mineBlock(block, header, sealhash, id, secondSeed, abort, found, logger)
+		return
+	}
+search_luck:
+	for {
+		select {
+		case <-abort:
+			// Mining terminated, abort
+			logger.Trace("Tppow first nonce search aborted", "attempts", firstNonce-firstSeed)
+			return
+
+		default:
+			// Argon2 is slow enough for every attempt to be accounted for directly
+			aHash := d.SealLuck(header, firstNonce)
+			d.luckHashrate.Mark(1)
+
+			if aHash.Cmp(header.DifficultyAlpha) < 0 {
+				break search_luck
+			}
+			firstNonce++
+		}
+	}
+	d.fillLuck(header, firstNonce)
+	d.cacheLuck(header)
+
+	logger.Debug("Tppow first nonce found", "firstNonce", firstNonce, "lucky", header.Lucky, "beta", header.DifficultyBeta)
+	d.feeds.post(LuckFoundEvent{
+		Miner:          id,
+		Number:         header.Number.Uint64(),
+		SealHash:       sealhash,
+		FirstNonce:     header.FirstNonce,
+		Lucky:          new(big.Int).Set(header.Lucky),
+		DifficultyBeta: new(big.Int).Set(header.DifficultyBeta),
+	})
+	d.mineBlock(block, header, sealhash, id, secondSeed, abort, found, logger)
+}
+
+// mineBlock is the second stage of the proof-of-work miner, searching for a
+// second nonce starting from secondSeed that satisfies the DifficultyBeta of a
+// header with its luck already filled in.
+func (d *Tppow) mineBlock(block *types.Block, header *types.Header, sealhash common.Hash, id int, secondSeed uint64, abort chan struct{}, found chan *types.Block, logger log.Logger) {
+	var (
+		firstNonce  = header.FirstNonce.Uint64()
+		secondNonce = secondSeed
+		lucky       = header.Lucky
+	)
+	for {
+		select {
+		case <-abort:
+			// Mining terminated, abort
+			logger.Trace("Tppow second nonce search aborted", "attempts", secondNonce-secondSeed)
+			return
+
+		default:
+			b := d.SealBlock(header, secondNonce)
+			d.blockHashrate.Mark(1)
+
+			if b.Cmp(header.DifficultyBeta) < 0 {
+				// Correct nonce pair found, create a new header with it
+				header = types.CopyHeader(header)
+				header.SecondNonce = types.EncodeNonce(secondNonce)
+
+				// Seal and return a block (if still needed)
+				sealed := block.WithSeal(header)
+				select {
+				case found <- sealed:
+					logger.Debug("Tppow nonces found and reported", "lucky", lucky, "firstNonce", firstNonce, "secondNonce", secondNonce)
+					d.feeds.post(NonceFoundEvent{
+						Miner:       id,
+						Block:       sealed,
+						SealHash:    sealhash,
+						FirstNonce:  header.FirstNonce,
+						SecondNonce: header.SecondNonce,
+						Lucky:       new(big.Int).Set(lucky),
+					})
+				case <-abort:
+					logger.Trace("Tppow nonces found but discarded", "lucky", lucky, "firstNonce", firstNonce, "secondNonce", secondNonce)
+				}
+				return
+			}
+			secondNonce++
+		}
+	}
+}
+
+// fillLuck derives the luck dependent header fields from a first nonce that
+// satisfies the header's DifficultyAlpha.
+func (d *Tppow) fillLuck(header *types.Header, firstNonce uint64) {
+	d.applyLuck(header, firstNonce, d.calcLuck(header, firstNonce))
+}
+
+// applyLuck sets the first nonce and the luck derived from it on a header, along
+// with the difficulties depending on the luck.
+func (d *Tppow) applyLuck(header *types.Header, firstNonce uint64, lucky *big.Int) {
+	header.FirstNonce = types.EncodeNonce(firstNonce)
+	header.Lucky = lucky
+	header.DifficultyBeta = d.calcBeta(header.Lucky, header.Basis)
+	header.Difficulty = d.calcDifficulty(header)
+}
+
+// This is the timeout for HTTP requests to notify external miners.
+const remoteSealerTimeout = 1 * time.Second
+
+type remoteSealer struct {
+	works        map[common.Hash]*types.Block
+	rates        map[common.Hash]hashrate
+	currentBlock *types.Block
+	currentWork  [6]string
+	notifyCtx    context.Context
+	cancelNotify context.CancelFunc // cancels all notification requests
+	reqWG        sync.WaitGroup     // tracks notification request goroutines
+
+	tppow        *Tppow
+	noverify     bool
+	notifyURLs   []string
+	results      chan<- *types.Block
+	stop         <-chan struct{}  // Quit channel of the current work, superseding pending solutions
+	workCh       chan *sealTask   // Notification channel to push new work and relative result channel to remote sealer
+	fetchWorkCh  chan *sealWork   // Channel used for remote sealer to fetch mining work
+	submitLuckCh chan *luckResult // Channel used for remote sealer to submit their first stage result
+	submitWorkCh chan *mineResult // Channel used for remote sealer to submit their mining result
+	verifiedCh   chan *mineResult // Channel used to hand verified mining results back to the loop
+	fetchRateCh  chan chan uint64 // Channel used to gather submitted hash rate for local or remote sealer.
+	submitRateCh chan *hashrate   // Channel used for remote sealer to submit their mining hashrate
+	verifiers    chan struct{}    // Semaphore bounding the concurrent verifications of remote submissions
+	requestExit  chan struct{}
+	exitCh       chan struct{}
+}
+
+// sealTask wraps a seal block with relative result channel for remote sealer thread.
+type sealTask struct {
+	block   *types.Block
+	results chan<- *types.Block
+	stop    <-chan struct{}
+}
+
+// luckResult wraps the first stage pow solution for the specified block.
+type luckResult struct {
+	firstNonce types.BlockNonce
+	hash       common.Hash
+
+	errc chan error
+	res  chan [4]string
+}
+
+// mineResult wraps the pow solution parameters for the specified block.
+type mineResult struct {
+	firstNonce  types.BlockNonce
+	secondNonce types.BlockNonce
+	hash        common.Hash
+	solution    *types.Block // Sealed block, set once the solution is verified
+
+	errc chan error
+}
+
+// hashrate wraps the hash rate submitted by the remote sealer.
+type hashrate struct {
+	id   common.Hash
+	ping time.Time
+	rate uint64
+
+	done chan struct{}
+}
+
+// sealWork wraps a seal work package for remote sealer.
+type sealWork struct {
+	errc chan error
+	res  chan [6]string
+}
+
+func startRemoteSealer(tppow *Tppow, urls []string, noverify bool) *remoteSealer {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &remoteSealer{
+		tppow:        tppow,
+		noverify:     noverify,
+		notifyURLs:   urls,
+		notifyCtx:    ctx,
+		cancelNotify: cancel,
+		works:        make(map[common.Hash]*types.Block),
+		rates:        make(map[common.Hash]hashrate),
+		workCh:       make(chan *sealTask),
+		fetchWorkCh:  make(chan *sealWork),
+		submitLuckCh: make(chan *luckResult),
+		submitWorkCh: make(chan *mineResult),
+		verifiedCh:   make(chan *mineResult),
+		fetchRateCh:  make(chan chan uint64),
+		submitRateCh: make(chan *hashrate),
+		verifiers:    make(chan struct{}, maxRemoteVerifiers),
+		requestExit:  make(chan struct{}),
+		exitCh:       make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *remoteSealer) loop() {
+	defer func() {
+		log.Trace("Tppow remote sealer is exiting")
+		s.cancelNotify()
+		s.reqWG.Wait()
+		close(s.exitCh)
+	}()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case work := <-s.workCh:
+			// Update current work with new received block.
+			// Note same work can be past twice, happens when changing CPU threads.
+			s.results = work.results
+			s.stop = work.stop
+			s.makeWork(work.block)
+			s.notifyWork()
+
+		case work := <-s.fetchWorkCh:
+			// Return current mining work to remote miner.
+			if s.currentBlock == nil {
+				work.errc <- errNoMiningWork
+			} else {
+				work.res <- s.currentWork
+			}
+
+		case result := <-s.submitLuckCh:
+			// Verify the first stage solution and hand out the second stage work.
+			// The Argon2 hashes run in the background not to stall the loop, on
+			// slots of the remote sealer not to compete with block import.
+			if block, err := s.pendingWork(result.hash, "Luck"); err != nil {
+				result.errc <- err
+			} else if !s.reserveVerifier() {
+				result.errc <- errBusy
+			} else {
+				go s.submitLuck(block, result)
+			}
+
+		case result := <-s.submitWorkCh:
+			// Verify submitted PoW solution based on maintained mining blocks.
+			// The Argon2 hashes run in the background not to stall the loop, on
+			// slots of the remote sealer not to compete with block import.
+			if block, err := s.pendingWork(result.hash, "Work"); err != nil {
+				result.errc <- errInvalidSealResult
+			} else if !s.reserveVerifier() {
+				result.errc <- errBusy
+			} else {
+				go s.verifyWork(block, result)
+			}
+
+		case result := <-s.verifiedCh:
+			// Hand a verified PoW solution over to the miner.
+			if s.submitWork(result.solution, result.hash) {
+				result.errc <- nil
+			} else {
+				result.errc <- errInvalidSealResult
+			}
+
+		case result := <-s.submitRateCh:
+			// Trace remote sealer's hash rate by submitted value.
+			s.rates[result.id] = hashrate{rate: result.rate, ping: time.Now()}
+			close(result.done)
+
+		case req := <-s.fetchRateCh:
+			// Gather all hash rate submitted by remote sealer.
+			var total uint64
+			for _, rate := range s.rates {
+				// this could overflow
+				total += rate.rate
+			}
+			req <- total
+
+		case <-ticker.C:
+			// Clear stale submitted hash rate.
+			for id, rate := range s.rates {
+				if time.Since(rate.ping) > 10*time.Second {
+					delete(s.rates, id)
+				}
+			}
+			// Clear stale pending blocks
+			if s.currentBlock != nil {
+				for hash, block := range s.works {
+					if block.NumberU64()+staleThreshold <= s.currentBlock.NumberU64() {
+						delete(s.works, hash)
+					}
+				}
+			}
+
+		case <-s.requestExit:
+			return
+		}
+	}
+}
+
+// makeWork creates a first stage work package for external miner.
+//
+// The work package consists of 6 strings:
+//   result[0], 32 bytes hex encoded current block header seal hash, identifying the work
+//   result[1], 32 bytes hex encoded parent hash
+//   result[2], 25 bytes hex encoded coinbase
+//   result[3], hex encoded timestamp
+//   result[4], hex encoded first stage boundary condition ("target"), DifficultyAlpha
+//   result[5], hex encoded block number
+func (s *remoteSealer) makeWork(block *types.Block) {
+	header := block.Header()
+	hash := s.tppow.SealHash(header)
+
+	s.currentWork[0] = hash.Hex()
+	s.currentWork[1] = header.ParentHash.Hex()
+	s.currentWork[2] = header.Coinbase.Hex()
+	s.currentWork[3] = hexutil.EncodeUint64(header.Time)
+	s.currentWork[4] = hexutil.EncodeBig(header.DifficultyAlpha)
+	s.currentWork[5] = hexutil.EncodeBig(header.Number)
+
+	// Trace the seal work fetched by remote sealer.
+	s.currentBlock = block
+	s.works[hash] = block
+}
+
+// notifyWork notifies all the specified mining endpoints of the availability of
+// new work to be processed.
+func (s *remoteSealer) notifyWork() {
+	work := s.currentWork
+	blob, _ := json.Marshal(work)
+	s.reqWG.Add(len(s.notifyURLs))
+	for _, url := range s.notifyURLs {
+		go s.sendNotification(s.notifyCtx, url, blob, work)
+	}
+}
+
+func (s *remoteSealer) sendNotification(ctx context.Context, url string, json []byte, work [6]string) {
+	defer s.reqWG.Done()
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(json))
+	if err != nil {
+		log.Warn("Can't create remote miner notification", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteSealerTimeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Warn("Failed to notify remote miner", "err", err)
+	} else {
+		log.Trace("Notified remote miner", "miner", url, "hash", work[0], "target", work[4])
+		resp.Body.Close()
+	}
+}
+
+// pendingWork looks up a work package handed out to remote miners, for which
+// a solution of the given kind was submitted.
+func (s *remoteSealer) pendingWork(sealhash common.Hash, kind string) (*types.Block, error) {
+	if s.currentBlock == nil {
+		log.Error("Pending work without block", "sealhash", sealhash)
+		return nil, errNoMiningWork
+	}
+	// Make sure the work submitted is present
+	block := s.works[sealhash]
+	if block == nil {
+		log.Warn(kind+" submitted but none pending", "sealhash", sealhash, "curnumber", s.currentBlock.NumberU64())
+		return nil, errInvalidSealResult
+	}
+	return block, nil
+}
+
+// reserveVerifier takes one of the verification slots of the remote sealer,
+// returning false without waiting if all of them are in use. The slot is
+// released by the goroutine verifying the submission.
+func (s *remoteSealer) reserveVerifier() bool {
+	select {
+	case s.verifiers <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// submitLuck verifies the submitted first stage solution and derives the second
+// stage work package from it. It runs outside of the remote sealer loop, as the
+// result only depends on the work package, and replies to the submitter itself.
+//
+// The second stage work package consists of 4 strings:
+//   result[0], 32 bytes hex encoded block header seal hash, identifying the work
+//   result[1], hex encoded luck derived from the first nonce
+//   result[2], hex encoded second stage boundary condition ("target"), DifficultyBeta
+//   result[3], hex encoded RLP of the header to search the second nonce for
+func (s *remoteSealer) submitLuck(block *types.Block, result *luckResult) {
+	var (
+		header     = block.Header()
+		firstNonce = result.firstNonce.Uint64()
+	)
+	valid := s.tppow.SealLuck(header, firstNonce).Cmp(header.DifficultyAlpha) < 0
+	if valid {
+		s.tppow.fillLuck(header, firstNonce)
+	}
+	<-s.verifiers
+
+	if !valid {
+		log.Warn("Invalid first stage proof-of-work submitted", "sealhash", result.hash, "firstNonce", firstNonce)
+		result.errc <- errInvalidSealResult
+		return
+	}
+	s.tppow.cacheLuck(header)
+
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		result.errc <- err
+		return
+	}
+	result.res <- [4]string{
+		result.hash.Hex(),
+		hexutil.EncodeBig(header.Lucky),
+		hexutil.EncodeBig(header.DifficultyBeta),
+		hexutil.Encode(blob),
+	}
+}
+
+// verifyWork verifies the submitted pow solution outside of the remote sealer
+// loop, handing it back to the loop for submission if valid. The seal is hashed
+// directly instead of through the engine's verifier, whose slots are reserved
+// for block import.
+func (s *remoteSealer) verifyWork(block *types.Block, result *mineResult) {
+	header := block.Header()
+	start := time.Now()
+
+	s.tppow.fillLuck(header, result.firstNonce.Uint64())
+	header.SecondNonce = result.secondNonce
+
+	var err error
+	if !s.noverify {
+		err = s.tppow.verifySeal(header)
+	}
+	<-s.verifiers
+
+	if err != nil {
+		log.Warn("Invalid proof-of-work submitted", "sealhash", result.hash, "elapsed", common.PrettyDuration(time.Since(start)), "err", err)
+		result.errc <- errInvalidSealResult
+		return
+	}
+	log.Trace("Verified correct proof-of-work", "sealhash", result.hash, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	result.solution = block.WithSeal(header)
+	select {
+	case s.verifiedCh <- result:
+	case <-s.exitCh:
+		result.errc <- errTppowStopped
+	}
+}
+
+// submitWork submits a verified pow solution to the miner, returning
+// whether the solution was accepted or not (not can be both a bad pow as well as
+// any other error, like no pending work or stale mining result).
+func (s *remoteSealer) submitWork(solution *types.Block, sealhash common.Hash) bool {
+	// Make sure the result channel is assigned.
+	if s.results == nil {
+		log.Warn("Tppow result channel is empty, submitted mining result is rejected")
+		return false
+	}
+	// The submitted solution is within the scope of acceptance. Hand it over in
+	// the background, as waiting for the miner would stall the remote sealer loop.
+	if solution.NumberU64()+staleThreshold > s.currentBlock.NumberU64() {
+		log.Debug("Work submitted is acceptable", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
+		go s.tppow.deliverSeal("remote", solution, s.results, s.stop)
+		return true
+	}
+	// The submitted block is too old to accept, drop it.
+	log.Warn("Work submitted is too old", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
+	return false
+}
@@ -0,0 +1,436 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/core/types"
+)
+
+// Tests that the nonce slices handed to the mining threads never overlap.
+func TestNonceOffset(t *testing.T) {
+	for _, threads := range []int{1, 2, 3, 16, 64} {
+		span := nonceOffset(1, threads)
+		for i := 1; i < threads; i++ {
+			if have, want := nonceOffset(i, threads)-nonceOffset(i-1, threads), span; have != want {
+				t.Errorf("threads %d: slice %d size mismatch: have %d, want %d", threads, i, have, want)
+			}
+		}
+		if last := nonceOffset(threads-1, threads); last > math.MaxUint64-span {
+			t.Errorf("threads %d: last slice wraps into the first one", threads)
+		}
+	}
+}
+
+// Tests whether remote HTTP servers are correctly notified of new work.
+func TestRemoteNotify(t *testing.T) {
+	// Start a simple web server to capture notifications.
+	sink := make(chan [6]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		blob, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("failed to read miner notification: %v", err)
+		}
+		var work [6]string
+		if err := json.Unmarshal(blob, &work); err != nil {
+			t.Errorf("failed to unmarshal miner notification: %v", err)
+		}
+		sink <- work
+	}))
+	defer server.Close()
+
+	// Create the custom tppow engine with local mining disabled.
+	tppow := New(nil, []string{server.URL}, false)
+	tppow.SetThreads(-1)
+	defer tppow.Close()
+
+	// Stream a work task and ensure the notification bubbles out.
+	header := &types.Header{
+		ParentHash:      common.HexToHash("0x01"),
+		Coinbase:        common.HexToAddress("0x02"),
+		Number:          big.NewInt(1),
+		Time:            1600000000,
+		DifficultyAlpha: big.NewInt(100),
+	}
+	block := types.NewBlockWithHeader(header)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	tppow.Seal(nil, block, nil, stop)
+
+	select {
+	case work := <-sink:
+		if want := tppow.SealHash(header).Hex(); work[0] != want {
+			t.Errorf("work packet hash mismatch: have %s, want %s", work[0], want)
+		}
+		if want := header.ParentHash.Hex(); work[1] != want {
+			t.Errorf("work packet parent mismatch: have %s, want %s", work[1], want)
+		}
+		if want := header.Coinbase.Hex(); work[2] != want {
+			t.Errorf("work packet coinbase mismatch: have %s, want %s", work[2], want)
+		}
+		if want := hexutil.EncodeUint64(header.Time); work[3] != want {
+			t.Errorf("work packet time mismatch: have %s, want %s", work[3], want)
+		}
+		if want := hexutil.EncodeBig(header.DifficultyAlpha); work[4] != want {
+			t.Errorf("work packet target mismatch: have %s, want %s", work[4], want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("notification timed out")
+	}
+}
+
+// Tests that solutions for unknown work packages are rejected without being
+// verified.
+func TestRemoteUnknownSubmission(t *testing.T) {
+	tppow := New(nil, nil, false)
+	tppow.SetThreads(-1)
+	defer tppow.Close()
+
+	api := &API{tppow: tppow}
+	if _, err := api.GetWork(); err != errNoMiningWork {
+		t.Errorf("work fetch error mismatch: have %v, want %v", err, errNoMiningWork)
+	}
+	header := &types.Header{Number: big.NewInt(1), DifficultyAlpha: big.NewInt(100)}
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	tppow.Seal(nil, types.NewBlockWithHeader(header), results, stop)
+
+	if _, err := api.GetWork(); err != nil {
+		t.Fatalf("failed to fetch work: %v", err)
+	}
+	if _, err := api.SubmitLuck(types.EncodeNonce(1), common.HexToHash("0xdeadbeef")); err != errInvalidSealResult {
+		t.Errorf("luck submission error mismatch: have %v, want %v", err, errInvalidSealResult)
+	}
+	if api.SubmitWork(types.EncodeNonce(1), types.EncodeNonce(2), common.HexToHash("0xdeadbeef")) {
+		t.Errorf("unknown work accepted")
+	}
+	select {
+	case <-results:
+		t.Errorf("unexpected result delivered")
+	default:
+	}
+}
+
+// Tests that remote solutions the miner doesn't read right away are handed over
+// once it does without stalling the remote sealer, and are only discarded once
+// superseded by new work.
+func TestRemoteUnreadSubmission(t *testing.T) {
+	tppow := New(nil, nil, true)
+	tppow.SetThreads(-1)
+	defer tppow.Close()
+
+	discards := make(chan SealDiscardedEvent, 1)
+	defer tppow.SubscribeSealDiscarded(discards).Unsubscribe()
+
+	api := &API{tppow: tppow}
+	header := &types.Header{Number: big.NewInt(1), Basis: big.NewInt(1000), DifficultyAlpha: big.NewInt(100)}
+	results := make(chan *types.Block)
+	stop := make(chan struct{})
+	tppow.Seal(nil, types.NewBlockWithHeader(header), results, stop)
+
+	work, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to fetch work: %v", err)
+	}
+	sealhash := common.HexToHash(work[0])
+
+	// A busy miner eventually reading the result gets the solution
+	if !api.SubmitWork(types.EncodeNonce(1), types.EncodeNonce(2), sealhash) {
+		t.Fatalf("unread solution rejected")
+	}
+	if _, err := api.GetWork(); err != nil {
+		t.Errorf("remote sealer stalled: %v", err)
+	}
+	select {
+	case block := <-results:
+		if block.Header().SecondNonce != types.EncodeNonce(2) || tppow.SealHash(block.Header()) != sealhash {
+			t.Errorf("delivered solution mismatch: have %+v", block.Header())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("solution not delivered")
+	}
+	// A miner moving on to new work supersedes the solution
+	if !api.SubmitWork(types.EncodeNonce(1), types.EncodeNonce(3), sealhash) {
+		t.Fatalf("unread solution rejected")
+	}
+	select {
+	case ev := <-discards:
+		t.Fatalf("solution discarded before new work: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(stop)
+	select {
+	case ev := <-discards:
+		if ev.Mode != "remote" || ev.SealHash != sealhash {
+			t.Errorf("discard event mismatch: have %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no discard event posted")
+	}
+}
+
+// Tests that remote submissions are verified on slots of the remote sealer, not
+// competing with block import for the consensus verifiers and being rejected
+// instead of queued while all slots are taken.
+func TestRemoteSubmissionHashing(t *testing.T) {
+	tppow := New(nil, nil, false)
+	tppow.SetThreads(-1)
+	defer tppow.Close()
+
+	api := &API{tppow: tppow}
+	boundary := new(big.Int).Lsh(common.Big1, 256)
+	header := &types.Header{Number: big.NewInt(1), Basis: boundary, DifficultyAlpha: boundary}
+	stop := make(chan struct{})
+	defer close(stop)
+	tppow.Seal(nil, types.NewBlockWithHeader(header), make(chan *types.Block, 1), stop)
+
+	work, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to fetch work: %v", err)
+	}
+	sealhash := common.HexToHash(work[0])
+
+	// Take all remote verification slots, submissions must be rejected
+	remote := tppow.remote
+	for i := 0; i < cap(remote.verifiers); i++ {
+		remote.verifiers <- struct{}{}
+	}
+	if _, err := api.SubmitLuck(types.EncodeNonce(1), sealhash); err != errBusy {
+		t.Errorf("busy luck submission error mismatch: have %v, want %v", err, errBusy)
+	}
+	if api.SubmitWork(types.EncodeNonce(1), types.EncodeNonce(2), sealhash) {
+		t.Errorf("busy work submission accepted")
+	}
+	if _, err := api.GetWork(); err != nil {
+		t.Errorf("remote sealer stalled: %v", err)
+	}
+	for i := 0; i < cap(remote.verifiers); i++ {
+		<-remote.verifiers
+	}
+	// Take all consensus verification slots, submissions must not wait for them
+	for i := 0; i < cap(tppow.verifiers); i++ {
+		tppow.verifiers <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(tppow.verifiers); i++ {
+			<-tppow.verifiers
+		}
+	}()
+	lucks, works := make(chan error, 1), make(chan bool, 1)
+	go func() {
+		_, err := api.SubmitLuck(types.EncodeNonce(1), sealhash)
+		lucks <- err
+	}()
+	select {
+	case err := <-lucks:
+		if err != nil {
+			t.Errorf("luck submission failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("luck submission timed out")
+	}
+	go func() {
+		works <- api.SubmitWork(types.EncodeNonce(1), types.EncodeNonce(2), sealhash)
+	}()
+	select {
+	case ok := <-works:
+		if !ok {
+			t.Errorf("work submission rejected")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("work submission timed out")
+	}
+}
+
+// Tests that local sealing reports its progress on the event feeds.
+func TestSealEvents(t *testing.T) {
+	tppow := New(nil, nil, false)
+	tppow.SetThreads(1)
+	defer tppow.Close()
+
+	lucks := make(chan LuckFoundEvent, 1)
+	defer tppow.SubscribeLuckFound(lucks).Unsubscribe()
+	nonces := make(chan NonceFoundEvent, 1)
+	defer tppow.SubscribeNonceFound(nonces).Unsubscribe()
+
+	// Any nonce pair satisfies the boundaries, so the first attempts succeed
+	boundary := new(big.Int).Lsh(common.Big1, 256)
+	header := &types.Header{Number: big.NewInt(1), Basis: boundary, DifficultyAlpha: boundary}
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := tppow.Seal(nil, types.NewBlockWithHeader(header), results, stop); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	sealhash := tppow.SealHash(header)
+
+	var luck LuckFoundEvent
+	select {
+	case luck = <-lucks:
+		if luck.SealHash != sealhash || luck.Number != 1 || luck.Miner != 0 {
+			t.Errorf("luck event mismatch: have %+v", luck)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("luck event timed out")
+	}
+	select {
+	case ev := <-nonces:
+		if ev.SealHash != sealhash || ev.FirstNonce != luck.FirstNonce || ev.Lucky.Cmp(luck.Lucky) != 0 {
+			t.Errorf("nonce event mismatch: have %+v, luck %+v", ev, luck)
+		}
+		select {
+		case block := <-results:
+			if block.Hash() != ev.Block.Hash() {
+				t.Errorf("sealed block mismatch: have %x, want %x", block.Hash(), ev.Block.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("sealed block not delivered")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("nonce event timed out")
+	}
+}
+
+// Tests that subscribers never reading their events don't hold up sealing.
+func TestSealEventsStalled(t *testing.T) {
+	tppow := New(nil, nil, false)
+	tppow.SetThreads(2)
+	defer tppow.Close()
+
+	defer tppow.SubscribeLuckFound(make(chan LuckFoundEvent)).Unsubscribe()
+	defer tppow.SubscribeNonceFound(make(chan NonceFoundEvent)).Unsubscribe()
+
+	boundary := new(big.Int).Lsh(common.Big1, 256)
+	for i := 0; i < 3; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i + 1)), Basis: boundary, DifficultyAlpha: boundary}
+		results := make(chan *types.Block, 1)
+		stop := make(chan struct{})
+		if err := tppow.Seal(nil, types.NewBlockWithHeader(header), results, stop); err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		select {
+		case <-results:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("block %d: sealing stalled by subscribers", i+1)
+		}
+		close(stop)
+	}
+}
+
+// Tests that sealed blocks wait for the miner to read them and are only dropped,
+// explicitly, once superseded by new work.
+func TestDeliverSeal(t *testing.T) {
+	tppow := NewFaker()
+	defer tppow.Close()
+
+	discards := make(chan SealDiscardedEvent, 1)
+	defer tppow.SubscribeSealDiscarded(discards).Unsubscribe()
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+
+	// A busy miner eventually reading the result gets the block
+	results := make(chan *types.Block)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		<-results
+	}()
+	if !tppow.deliverSeal("local", block, results, make(chan struct{})) {
+		t.Errorf("block not delivered to busy miner")
+	}
+	// A miner moving on to new work supersedes the block
+	stop := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+	}()
+	if tppow.deliverSeal("local", block, results, stop) {
+		t.Errorf("block delivered without miner reading it")
+	}
+	select {
+	case ev := <-discards:
+		if ev.Mode != "local" || ev.Block != block || ev.SealHash != tppow.SealHash(block.Header()) {
+			t.Errorf("discard event mismatch: have %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no discard event posted")
+	}
+	// A superseded block is still handed over if the result channel has room
+	if !tppow.deliverSeal("local", block, make(chan *types.Block, 1), stop) {
+		t.Errorf("block not delivered to free result channel")
+	}
+}
+
+// Tests that the first stage solution of a work package is reused when only its
+// transactions change, while any change to its first stage inputs restarts it.
+func TestSealLuckReuse(t *testing.T) {
+	tppow := New(nil, nil, false)
+	tppow.SetThreads(1)
+	defer tppow.Close()
+
+	lucks := make(chan LuckFoundEvent, 1)
+	defer tppow.SubscribeLuckFound(lucks).Unsubscribe()
+
+	// Any first nonce satisfies the boundary, but no second one ever does
+	header := &types.Header{
+		ParentHash:      common.HexToHash("0x01"),
+		Coinbase:        common.HexToAddress("0x02"),
+		Number:          big.NewInt(1),
+		Time:            1600000000,
+		Basis:           new(big.Int),
+		DifficultyAlpha: new(big.Int).Lsh(common.Big1, 256),
+	}
+	seal := func(header *types.Header) bool {
+		stop := make(chan struct{})
+		defer close(stop)
+		if err := tppow.Seal(nil, types.NewBlockWithHeader(header), nil, stop); err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		select {
+		case <-lucks:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+	if !seal(header) {
+		t.Fatalf("first stage not searched for new work")
+	}
+	// A recommit with new transactions resumes with the found first nonce
+	recommit := types.CopyHeader(header)
+	recommit.TxHash = common.HexToHash("0x03")
+	if seal(recommit) {
+		t.Errorf("first stage searched again for recommitted work")
+	}
+	// New work with another coinbase needs a fresh first stage solution
+	rebase := types.CopyHeader(header)
+	rebase.Coinbase = common.HexToAddress("0x04")
+	if !seal(rebase) {
+		t.Errorf("first stage not searched for work with a new coinbase")
+	}
+}
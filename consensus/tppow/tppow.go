@@ -1,74 +1,277 @@
 package tppow
 
 import (
-	"fmt"
 	"errors"
-	"github.com/luck/go-luck/common"
-	//"github.com/luck/go-luck/crypto"
-	"github.com/luck/go-luck/core/types"
-	"github.com/luck/go-luck/consensus"
-	"github.com/luck/go-luck/core/state"
-	"github.com/luck/go-luck/rpc"
-	//lru "github.com/hashicorp/golang-lru"
-	"github.com/luck/go-luck/params"
-	"github.com/luck/go-luck/crypto"
-	"time"
-	//"io/ioutil"
-	//"strings"
-	//"strconv"
+	"fmt"
 	"math/big"
 	"math/rand"
-	"math"
-	"sync"
-	//"os"
 	"runtime"
-	//"github.com/luck/go-luck/common/math"
-	//"github.com/luck/go-luck/node"
-	//set "gopkg.in/fatih/set.v0"
+	"sync"
+	"time"
+
 	mapset "github.com/deckarep/golang-set"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/core/state"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/crypto"
+	"github.com/luck/go-luck/metrics"
+	"github.com/luck/go-luck/params"
 	"github.com/luck/go-luck/rlp"
+	"github.com/luck/go-luck/rpc"
 	"golang.org/x/crypto/sha3"
-	crand "crypto/rand"
-)	
+)
+
+const (
+	inmemorySeals = 4096 // Number of recently verified seals to keep in memory
+	inmemoryLucks = 16   // Number of recently found first stage solutions to keep in memory
+	maxVerifiers  = 4    // Maximum number of seals verified concurrently, each needing an Argon2 memory area
+
+	maxAPIVerifiers = 1 // Maximum number of seals verified concurrently on behalf of RPC callers
+)
 
 var (
+	initBasis           *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 186), common.Big1)
+	initDifficultyAlpha *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 190), common.Big1)
+	max256              *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
 
-	blockReward  *big.Int = big.NewInt(1e+18)
+	maxUncles = 2 // Maximum number of uncles allowed in a single block
 
-	maxLuck *big.Int = big.NewInt(2e+8)
+	allowedFutureBlockTime = 15 * time.Second // Max time from current time allowed for blocks, before they're considered future blocks
 
-	HashScale *big.Int = big.NewInt(4e+18)
+	// errLargeBlockTime    = errors.New("timestamp too big")
+	// errZeroBlockTime     = errors.New("timestamp equals parent's")
+	errOlderBlockTime  = errors.New("timestamp older than parent")
+	errTooManyUncles   = errors.New("too many uncles")
+	errDuplicateUncle  = errors.New("duplicate uncle")
+	errUncleIsAncestor = errors.New("uncle is ancestor")
+	errDanglingUncle   = errors.New("uncle's parent is not ancestor")
+
+	errUnknownBlock = errors.New("unknown block")
+	errInvalidPoW   = errors.New("invalid proof-of-work")
+
+	// Checks of the two-stage seal and of the difficulty parameters a header
+	// derives from its parent. Failures are reported wrapped into a SealError.
+	ErrAlphaTarget        = errors.New("first stage seal misses alpha target")
+	ErrLuckMismatch       = errors.New("luck mismatch")
+	ErrBetaMismatch       = errors.New("beta difficulty mismatch")
+	ErrBetaTarget         = errors.New("second stage seal misses beta target")
+	ErrDifficultyMismatch = errors.New("difficulty mismatch")
+	ErrBasisMismatch      = errors.New("basis mismatch")
+	ErrAlphaMismatch      = errors.New("alpha difficulty mismatch")
+)
 
-	initBasis *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 186), common.Big1)
-	initDifficultyAlpha *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 190), common.Big1)
-	max256 *big.Int = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+// SealError is returned when a header fails one of the checks of its two-stage
+// proof-of-work or of the difficulty parameters derived from its parent.
+type SealError struct {
+	Err  error    // Failed check, one of the Err* values above
+	Have *big.Int // Value carried by or computed from the header
+	Want *big.Int // Expected value, or the exclusive bound for target checks
+}
 
-	difficultyAjustBlock uint64 = uint64(39200) 
+// Error implements error, reporting the failed check with both values.
+func (e *SealError) Error() string {
+	if e.Err == ErrAlphaTarget || e.Err == ErrBetaTarget {
+		return fmt.Sprintf("%v: have %v, want below %v", e.Err, e.Have, e.Want)
+	}
+	return fmt.Sprintf("%v: have %v, want %v", e.Err, e.Have, e.Want)
+}
 
-	maxUncles                     = 2 // Maximum number of uncles allowed in a single block
+// Unwrap returns the failed check, allowing errors.Is matching against it.
+func (e *SealError) Unwrap() error {
+	return e.Err
+}
 
-	allowedFutureBlockTime    = 15 * time.Second  // Max time from current time allowed for blocks, before they're considered future blocks
+// Mode defines the type and amount of PoW verification a tppow engine makes.
+type Mode uint
 
-	// errLargeBlockTime    = errors.New("timestamp too big")
-	// errZeroBlockTime     = errors.New("timestamp equals parent's")
-	errOlderBlockTime    = errors.New("timestamp older than parent")
-	errTooManyUncles     = errors.New("too many uncles")
-	errDuplicateUncle    = errors.New("duplicate uncle")
-	errUncleIsAncestor   = errors.New("uncle is ancestor")
-	errDanglingUncle     = errors.New("uncle's parent is not ancestor")
-
-	errInconsistence     = errors.New("param inconsistence")
-	errComputeLucky      = errors.New("compute the luck")
-	errUnknownBlock      = errors.New("mined block unknown")
+const (
+	ModeNormal Mode = iota
+	ModeFake
+	ModeFullFake
 )
 
+// Tppow is a consensus engine based on a two-stage proof-of-work: a first nonce
+// satisfying DifficultyAlpha determines the block luck, which in turn sets the
+// DifficultyBeta target a second nonce has to satisfy.
 type Tppow struct {
-	rand     *rand.Rand    // Properly seeded random source for nonces
+	config *params.TppowConfig // Consensus parameters, with defaults filled in
+
+	// Mining related fields
+	rand    *rand.Rand    // Properly seeded random source for nonces
+	threads int           // Number of threads to mine on if mining
+	update  chan struct{} // Notification channel to update mining parameters
+
+	luckHashrate  metrics.Meter // Meter tracking the average first stage (SealLuck) hashrate
+	blockHashrate metrics.Meter // Meter tracking the average second stage (SealBlock) hashrate
+	remote        *remoteSealer
+
+	seals     *lru.ARCCache // Hashes of recently verified headers to avoid rerunning Argon2
+	lucks     *lru.Cache    // First stage solutions of recently sealed work, reused on recommits
+	verifiers chan struct{} // Semaphore bounding the concurrent Argon2 seal verifications
+
+	apiVerifiers chan struct{} // Semaphore bounding the seal verifications requested over RPC
+
+	feeds sealFeeds // Feeds notifying subscribers of sealing progress
+
+	// The fields below are hooks for testing
+	mode      Mode          // Type and amount of PoW verification made
+	fakeFail  uint64        // Block number which fails PoW check even in fake mode
+	fakeDelay time.Duration // Time delay to sleep for before returning from verify
+
 	lock      sync.Mutex // Ensures thread safety for the in-memory caches and mining fields
+	closeOnce sync.Once  // Ensures exit channel will not be closed twice.
 }
 
-func New() *Tppow {
-	return &Tppow{}
+// New creates a full sized two-stage PoW scheme and starts a background thread
+// for remote mining, also optionally notifying a batch of remote services of new
+// work packages.
+func New(config *params.TppowConfig, notify []string, noverify bool) *Tppow {
+	seals, _ := lru.NewARC(inmemorySeals)
+	lucks, _ := lru.New(inmemoryLucks)
+	tppow := &Tppow{
+		config:        config.WithDefaults(),
+		update:        make(chan struct{}),
+		luckHashrate:  metrics.NewRegisteredMeterForced("tppow/hashrate/luck", nil),
+		blockHashrate: metrics.NewRegisteredMeterForced("tppow/hashrate/block", nil),
+		seals:         seals,
+		lucks:         lucks,
+		verifiers:     make(chan struct{}, verifiers()),
+		apiVerifiers:  make(chan struct{}, maxAPIVerifiers),
+	}
+	tppow.remote = startRemoteSealer(tppow, notify, noverify)
+	return tppow
+}
+
+// newFake creates a tppow consensus engine running in one of the fake modes,
+// without any background threads or remote sealing.
+func newFake(config *params.TppowConfig, mode Mode) *Tppow {
+	seals, _ := lru.NewARC(inmemorySeals)
+	lucks, _ := lru.New(inmemoryLucks)
+	return &Tppow{
+		config:        config.WithDefaults(),
+		update:        make(chan struct{}),
+		luckHashrate:  metrics.NilMeter{},
+		blockHashrate: metrics.NilMeter{},
+		seals:         seals,
+		lucks:         lucks,
+		verifiers:     make(chan struct{}, verifiers()),
+		apiVerifiers:  make(chan struct{}, maxAPIVerifiers),
+		mode:          mode,
+	}
+}
+
+// verifiers returns the number of seals allowed to be verified concurrently,
+// bounded so that syncing nodes don't allocate an Argon2 memory area on every
+// available core at once.
+func verifiers() int {
+	if n := runtime.GOMAXPROCS(0); n < maxVerifiers {
+		return n
+	}
+	return maxVerifiers
+}
+
+// NewFaker creates a tppow consensus engine with a fake PoW scheme that accepts
+// all blocks' seal as valid, though they still have to conform to the Luck
+// consensus rules.
+func NewFaker() *Tppow {
+	return newFake(nil, ModeFake)
+}
+
+// NewFakeFailer creates a tppow consensus engine with a fake PoW scheme that
+// accepts all blocks as valid apart from the single one specified, though they
+// still have to conform to the Luck consensus rules.
+func NewFakeFailer(fail uint64) *Tppow {
+	tppow := newFake(nil, ModeFake)
+	tppow.fakeFail = fail
+	return tppow
+}
+
+// NewFakeDelayer creates a tppow consensus engine with a fake PoW scheme that
+// accepts all blocks as valid, but delays verifications by some time, though
+// they still have to conform to the Luck consensus rules.
+func NewFakeDelayer(delay time.Duration) *Tppow {
+	tppow := newFake(nil, ModeFake)
+	tppow.fakeDelay = delay
+	return tppow
+}
+
+// NewFullFaker creates a tppow consensus engine with a full fake scheme that
+// accepts all blocks as valid, without checking any consensus rules whatsoever.
+func NewFullFaker() *Tppow {
+	return newFake(nil, ModeFullFake)
+}
+
+// NewFakerWithConfig creates a fake tppow consensus engine like NewFaker, but
+// using the given consensus parameters instead of the defaults.
+func NewFakerWithConfig(config *params.TppowConfig) *Tppow {
+	return newFake(config, ModeFake)
+}
+
+// NewFullFakerWithConfig creates a full fake tppow consensus engine like
+// NewFullFaker, but using the given consensus parameters instead of the defaults.
+func NewFullFakerWithConfig(config *params.TppowConfig) *Tppow {
+	return newFake(config, ModeFullFake)
+}
+
+// Threads returns the number of mining threads currently enabled. This doesn't
+// necessarily mean that mining is running!
+func (d *Tppow) Threads() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.threads
+}
+
+// SetThreads updates the number of mining threads currently enabled. Calling
+// this method does not start mining, only sets the thread count. If zero is
+// specified, the miner will use all cores of the machine. Setting a thread
+// count below zero is allowed and will cause the miner to idle, without any
+// work being done.
+func (d *Tppow) SetThreads(threads int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// Update the threads and ping any running seal to pull in any changes
+	d.threads = threads
+	select {
+	case d.update <- struct{}{}:
+	default:
+	}
+}
+
+// Hashrate implements PoW, returning the measured rate of Argon2 evaluations
+// per second over the last minute, summed over both search stages.
+// Note the returned hashrate includes local hashrate, but also includes the total
+// hashrate of all remote miner.
+func (d *Tppow) Hashrate() float64 {
+	local := d.LuckHashrate() + d.BlockHashrate()
+	if d.remote == nil {
+		return local
+	}
+	var res = make(chan uint64, 1)
+
+	select {
+	case d.remote.fetchRateCh <- res:
+	case <-d.remote.exitCh:
+		// Return local hashrate only if tppow is stopped.
+		return local
+	}
+	// Gather total submitted hash rate of remote sealers.
+	return local + float64(<-res)
+}
+
+// LuckHashrate returns the measured rate of first stage (SealLuck) evaluations
+// per second over the last minute.
+func (d *Tppow) LuckHashrate() float64 {
+	return d.luckHashrate.Rate1()
+}
+
+// BlockHashrate returns the measured rate of second stage (SealBlock) evaluations
+// per second over the last minute.
+func (d *Tppow) BlockHashrate() float64 {
+	return d.blockHashrate.Rate1()
 }
 
 func (d *Tppow) Author(header *types.Header) (common.Address, error) {
@@ -85,6 +288,10 @@ func (d *Tppow) CalcDifficulty(chain consensus.ChainReader, time uint64, parent
 }
 
 func (d *Tppow) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	// If we're running a full engine faking, accept any input as valid
+	if d.mode == ModeFullFake {
+		return nil
+	}
 	number := header.Number.Uint64()
 	if chain.GetHeader(header.Hash(), number) != nil {
 		return nil
@@ -93,11 +300,12 @@ func (d *Tppow) VerifyHeader(chain consensus.ChainReader, header *types.Header,
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	return d.verifyHeader(chain, header, parent, false, seal)
+	return d.verifyHeader(chain, header, parent, nil, false, seal)
 }
 
 func (d *Tppow) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
-	if len(headers) == 0 {
+	// If we're running a full engine faking, accept any input as valid
+	if d.mode == ModeFullFake || len(headers) == 0 {
 		abort, results := make(chan struct{}), make(chan error, len(headers))
 		for i := 0; i < len(headers); i++ {
 			results <- nil
@@ -105,8 +313,8 @@ func (d *Tppow) VerifyHeaders(chain consensus.ChainReader, headers []*types.Head
 		return abort, results
 	}
 
-	// Spawn as many workers as allowed threads
-	workers := runtime.GOMAXPROCS(0)
+	// Spawn as many workers as seals may be verified concurrently
+	workers := cap(d.verifiers)
 	if len(headers) < workers {
 		workers = len(headers)
 	}
@@ -170,10 +378,13 @@ func (d *Tppow) verifyHeaderWorker(chain consensus.ChainReader, headers []*types
 	if chain.GetHeader(headers[index].Hash(), headers[index].Number.Uint64()) != nil {
 		return nil // known block
 	}
-	return d.verifyHeader(chain, headers[index], parent, false, seals[index])
+	return d.verifyHeader(chain, headers[index], parent, headers[:index], false, seals[index])
 }
 
-func (d *Tppow) verifyHeader(chain consensus.ChainReader, header *types.Header, parent *types.Header, uncle bool, seal bool) error {
+// verifyHeader checks whether a header conforms to the consensus rules of the
+// Tppow engine. The optional batch of preceding, not yet imported headers is
+// used to resolve ancestors needed by the difficulty retarget.
+func (d *Tppow) verifyHeader(chain consensus.ChainReader, header *types.Header, parent *types.Header, batch []*types.Header, uncle bool, seal bool) error {
 	// Ensure that the header's extra-data section is of a reasonable size
 	if uint64(len(header.Extra)) > params.MaximumExtraDataSize {
 		return fmt.Errorf("extra-data too long: %d > %d", len(header.Extra), params.MaximumExtraDataSize)
@@ -219,14 +430,13 @@ func (d *Tppow) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	}
 
 	number := header.Number
-	basis, alpha := d.calcParam(chain, number.Uint64(), header.Time, parent)
+	basis, alpha := d.calcParam(chain, number.Uint64(), header.Time, parent, batch)
 
 	if basis.Cmp(header.Basis) != 0 {
-		return errInconsistence
+		return &SealError{ErrBasisMismatch, header.Basis, basis}
 	}
-
 	if alpha.Cmp(header.DifficultyAlpha) != 0 {
-		return errInconsistence
+		return &SealError{ErrAlphaMismatch, header.DifficultyAlpha, alpha}
 	}
 
 	// Verify the engine specific seal securing the block
@@ -243,6 +453,10 @@ func (d *Tppow) verifyCascadingFields(chain consensus.ChainReader, header *types
 }
 
 func (d *Tppow) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	// If we're running a full engine faking, accept any input as valid
+	if d.mode == ModeFullFake {
+		return nil
+	}
 	// Verify that there are at most 2 uncles included in this block
 	if len(block.Uncles()) > maxUncles {
 		return errTooManyUncles
@@ -281,7 +495,7 @@ func (d *Tppow) VerifyUncles(chain consensus.ChainReader, block *types.Block) er
 		if ancestors[uncle.ParentHash] == nil || uncle.ParentHash == block.ParentHash() {
 			return errDanglingUncle
 		}
-		if err := d.verifyHeader(chain, uncle, ancestors[uncle.ParentHash], true, true); err != nil {
+		if err := d.verifyHeader(chain, uncle, ancestors[uncle.ParentHash], nil, true, true); err != nil {
 			return err
 		}
 	}
@@ -289,32 +503,62 @@ func (d *Tppow) VerifyUncles(chain consensus.ChainReader, block *types.Block) er
 }
 
 func (d *Tppow) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
-	aHash := d.SealLuck(header, header.FirstNonce.Uint64())
-	if aHash.Cmp(header.DifficultyAlpha) >= 0{
-		return errUnknownBlock
+	// If we're running a fake PoW, accept any seal as valid
+	if d.mode == ModeFake || d.mode == ModeFullFake {
+		time.Sleep(d.fakeDelay)
+		if d.fakeFail == header.Number.Uint64() {
+			return errInvalidPoW
+		}
+		return nil
 	}
+	return d.VerifyHeaderSeal(header)
+}
 
-	beta := d.calcBeta(header.Lucky, header.Basis)
-
+// VerifyHeaderSeal checks the proof-of-work seal of a header in full, even if
+// the engine runs in a fake mode. It backs the seal verification precompile,
+// whose result must not depend on how the local engine is configured.
+func (d *Tppow) VerifyHeaderSeal(header *types.Header) error {
+	// Headers are frequently verified more than once (fetcher, downloader,
+	// uncles), skip the Argon2 stages if this one already passed
+	hash := header.Hash()
+	if d.seals.Contains(hash) {
+		return nil
+	}
+	d.verifiers <- struct{}{}
+	err := d.verifySeal(header)
+	<-d.verifiers
 
-	if beta.Cmp(header.DifficultyBeta) != 0 {
-		return errInconsistence
+	if err != nil {
+		return err
 	}
+	d.seals.Add(hash, struct{}{})
+	return nil
+}
 
+// verifySeal checks both stages of the proof-of-work of a header along with the
+// difficulty parameters derived from them.
+func (d *Tppow) verifySeal(header *types.Header) error {
+	aHash := d.SealLuck(header, header.FirstNonce.Uint64())
+	if aHash.Cmp(header.DifficultyAlpha) >= 0 {
+		return &SealError{ErrAlphaTarget, aHash, header.DifficultyAlpha}
+	}
+	// Check the luck before deriving anything from it, it must stay below the
+	// maximum luck for the beta calculation to be defined
 	sl := d.calcLuck(header, header.FirstNonce.Uint64())
-
 	if sl.Cmp(header.Lucky) != 0 {
-		return errComputeLucky
+		return &SealError{ErrLuckMismatch, header.Lucky, sl}
+	}
+	beta := d.calcBeta(header.Lucky, header.Basis)
+	if beta.Cmp(header.DifficultyBeta) != 0 {
+		return &SealError{ErrBetaMismatch, header.DifficultyBeta, beta}
 	}
-
 	b := d.SealBlock(header, header.SecondNonce.Uint64())
 	if b.Cmp(header.DifficultyBeta) >= 0 {
-		return errUnknownBlock
+		return &SealError{ErrBetaTarget, b, header.DifficultyBeta}
 	}
-
 	diff := d.calcDifficulty(header)
 	if diff.Cmp(header.Difficulty) != 0 {
-		return errUnknownBlock
+		return &SealError{ErrDifficultyMismatch, header.Difficulty, diff}
 	}
 
 	return nil
@@ -325,146 +569,41 @@ func (d *Tppow) Prepare(chain consensus.ChainReader, header *types.Header) error
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Basis, header.DifficultyAlpha = d.calcParam(chain, header.Number.Uint64(), header.Time, parent)
-		
+	header.Basis, header.DifficultyAlpha = d.calcParam(chain, header.Number.Uint64(), header.Time, parent, nil)
+
 	//fmt.Printf("parent.Time=%v, parent.DifficultyAlpha=%v, parent.DifficultyBeta=%v, parent.Basis=%v\r\n ",
 	//	parent.Time, parent.DifficultyAlpha, parent.DifficultyBeta, parent.Basis, )
 
 	return nil
 }
 
+// PrepareFromParent initializes the difficulty parameters of a header directly
+// from its parent, without looking anything up in the chain. It is used by chain
+// generators which construct blocks outside of a live blockchain.
+func (d *Tppow) PrepareFromParent(header *types.Header, parent *types.Header) {
+	header.Basis, header.DifficultyAlpha = d.calcParam(nil, header.Number.Uint64(), header.Time, parent, nil)
+}
+
 func (d *Tppow) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header) {
-	mineRewards(chain.Config(), state, header, uncles)
+	d.mineRewards(state, header, uncles)
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	// Header seems complete, assemble into a block and return
 	//return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
 func (d *Tppow) FinalizeAndAssemble(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
-	mineRewards(chain.Config(), state, header, uncles)
-	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
-	header.UncleHash = types.CalcUncleHash(nil)
-
-	// Assemble and return the final block for sealing
-	return types.NewBlock(header, txs, nil, receipts), nil
-}
-
-func (d *Tppow) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) (error) {
-	//number := block.Number()
-	abort := make(chan struct{})
-	found := make(chan *types.Block)
-
-	d.lock.Lock()
-	if d.rand == nil {
-		seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
-		if err != nil {
-			d.lock.Unlock()
-			return err
-		}
-		d.rand = rand.New(rand.NewSource(seed.Int64()))
-	}
-	d.lock.Unlock()
-
-
-	go func(fn uint64, sn uint64) {
-		d.mine(block, fn, sn, abort, found)
-	}(uint64(d.rand.Int63()), uint64(d.rand.Int63()))
-
-	var result *types.Block
-	select {
-	case <-stop:
-		// Outside abort, stop all miner threads
-		close(abort)
-	case result = <-found:
-		select {
-		case results <- result:
-		default:
-			fmt.Printf("not ready\r\n")
-		}
-		// One of the threads found a block, abort all others
-		close(abort)
-	}
-	// Wait for all miners to terminate and return the block
-	return nil
-}
-
-func (d *Tppow) mine(block *types.Block, fn uint64, sn uint64, abort chan struct{}, found chan *types.Block) {
-	var (
-		header = block.Header()
-		firstNonce = fn
-		secondNonce = sn
-		lucky = big.NewInt(0)
-
-		currFirstNonce = fn
-	)
-	//header.DifficultyBeta = new(big.Int).Set(beta)
-
-search_luck:
-	for {
-		select {
-		case <-abort:
-			// Mining terminated, update stats and abort
-			fmt.Printf("First nonce search aborted, firstNonce=%v\r\n", currFirstNonce)
-			//ethash.hashrate.Mark(attempts)
-			goto search_end
-			//break search
-		default:
-			currFirstNonce++
-
-
-			aHash := d.SealLuck(header, currFirstNonce)
-
-
-			if aHash.Cmp(header.DifficultyAlpha) < 0 {
-				firstNonce = currFirstNonce
-				lucky = d.calcLuck(header, firstNonce)
-				break search_luck
-			}
-		}
-	}
-	header.Lucky = new(big.Int).Set(lucky)
-	header.FirstNonce = types.EncodeNonce(firstNonce)
-	header.DifficultyBeta = d.calcBeta(lucky, header.Basis)
-	//header.Difficulty = d.calcDifficulty(lucky)
-	header.Difficulty = d.calcDifficulty(header)
-
-	//fmt.Printf("header.difficultybeta=%v, lucky=%v\r\n", header.DifficultyBeta, lucky)
-
-	//fmt.Printf("header.Time=%v, header.DifficultyAlpha=%v, header.Lucky=%v, header.DifficultyBeta=%v, header.Basis=%v\r\n",
-	//	header.Time, header.DifficultyAlpha, header.Lucky, header.DifficultyBeta, header.Basis)
-
-search_block:	
-	for {
-		select {
-		case <-abort:
-			// Mining terminated, update stats and abort
-			fmt.Printf("Second nonce search aborted, nonce=%v\r\n", secondNonce)
-			break search_block
-
-		default:
-			// We don't have to update hash rate on every nonce, so update after after 2^X nonces
-			secondNonce++
-
-			b := d.SealBlock(header, secondNonce)
-			if b.Cmp(header.DifficultyBeta) < 0 {
-				header.SecondNonce = types.EncodeNonce(secondNonce)
-				header = types.CopyHeader(header)
-				select {
-				case found <- block.WithSeal(header):
-					fmt.Printf("Luck nonce found and reported, lucky=%v, firstNonce=%v, secondNonce=%v\r\n", lucky, firstNonce, secondNonce)
-				case <-abort:
-					fmt.Printf("Luck nonce found but discarded lucky=%v, firstNonce=%v, secondNonce=%v\r\n", lucky, firstNonce, secondNonce)
-				}
-				break search_block
-			}
-		}
+	// Uncles are only included once the uncle policy is active
+	if !d.config.IsUncles(header.Number) {
+		uncles = nil
 	}
+	d.mineRewards(state, header, uncles)
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
-search_end:
-	//fmt.Printf("exit seal\r\n")
+	// Header seems complete, assemble into a block and return
+	return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
-func (d *Tppow) SealLuck(header *types.Header, nonce uint64) (*big.Int) {
+func (d *Tppow) SealLuck(header *types.Header, nonce uint64) *big.Int {
 	bs, _ := rlp.EncodeToBytes([]interface{}{
 		header.ParentHash,
 		header.Coinbase,
@@ -472,15 +611,14 @@ func (d *Tppow) SealLuck(header *types.Header, nonce uint64) (*big.Int) {
 		nonce,
 	})
 
-
-	hash := crypto.Argon2Hash(bs, header.ParentHash.Bytes()[22:])
+	hash := d.argon2(header, bs, header.ParentHash.Bytes()[22:])
 	res := new(big.Int).SetBytes(hash)
-	res = res.Div(res, HashScale)
+	res = res.Div(res, d.config.HashScale)
 	//res = res.Div(res, header.DifficultyAlpha)
 	return res
 }
 
-func (d *Tppow) calcLuck(header *types.Header, nonce uint64) (*big.Int) {
+func (d *Tppow) calcLuck(header *types.Header, nonce uint64) *big.Int {
 	//return big.NewInt(1000000)
 	bs, _ := rlp.EncodeToBytes([]interface{}{
 		header.ParentHash,
@@ -492,13 +630,13 @@ func (d *Tppow) calcLuck(header *types.Header, nonce uint64) (*big.Int) {
 
 	//fmt.Printf("222222 bytes=%v\r\n", header.ParentHash.Bytes()[20:])
 
-	hash := crypto.Argon2Hash(bs, header.ParentHash.Bytes()[20:])
+	hash := d.argon2(header, bs, header.ParentHash.Bytes()[20:])
 	res := new(big.Int).SetBytes(hash)
-	res = res.Mod(res, maxLuck)
+	res = res.Mod(res, d.config.MaxLuck)
 	return res
 }
 
-func (d *Tppow) SealBlock(header *types.Header, nonce uint64) (*big.Int) {
+func (d *Tppow) SealBlock(header *types.Header, nonce uint64) *big.Int {
 	bs, _ := rlp.EncodeToBytes([]interface{}{
 		header.ParentHash,
 		header.UncleHash,
@@ -524,13 +662,25 @@ func (d *Tppow) SealBlock(header *types.Header, nonce uint64) (*big.Int) {
 
 	//fmt.Printf("333333 bytes=%v\r\n", header.ParentHash.Bytes()[22:])
 
-	hash := crypto.Argon2Hash(bs, header.ParentHash.Bytes()[22:])
+	hash := d.argon2(header, bs, header.ParentHash.Bytes()[22:])
 	res := new(big.Int).SetBytes(hash)
-	res = res.Div(res, HashScale)
+	res = res.Div(res, d.config.HashScale)
 	//res = res.Div(res, header.DifficultyAlpha)
 	return res
 }
- 
+
+// argon2 hashes a seal stage input of the given header, using the Argon2 memory
+// size mandated by the consensus rules at the header's height.
+func (d *Tppow) argon2(header *types.Header, data []byte, salt []byte) []byte {
+	return crypto.Argon2HashWithMemory(data, salt, d.argon2Memory(header.Number))
+}
+
+// argon2Memory returns the Argon2 memory size in KiB mandated by the consensus
+// rules at the given height.
+func (d *Tppow) argon2Memory(number *big.Int) uint32 {
+	return d.config.Argon2Memory(number)
+}
+
 func (d *Tppow) SealHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
 
@@ -554,13 +704,30 @@ func (d *Tppow) SealHash(header *types.Header) (hash common.Hash) {
 	return hash
 }
 
-// APIs implements consensus.Engine, returning the user facing RPC API to allow
-// controlling the signer voting.
+// APIs implements consensus.Engine, returning the user facing RPC APIs.
 func (d *Tppow) APIs(chain consensus.ChainReader) []rpc.API {
-	return nil
+	return []rpc.API{
+		{
+			Namespace: "tppow",
+			Version:   "1.0",
+			Service:   &API{chain: chain, tppow: d},
+			Public:    true,
+		},
+	}
 }
 
+// Close closes the exit channel to notify all backend threads exiting.
 func (d *Tppow) Close() error {
+	d.closeOnce.Do(func() {
+		d.feeds.close()
+
+		// Short circuit if the exit channel is not allocated.
+		if d.remote == nil {
+			return
+		}
+		close(d.remote.requestExit)
+		<-d.remote.exitCh
+	})
 	return nil
 }
 
@@ -569,13 +736,26 @@ var (
 	big32 = big.NewInt(32)
 )
 
-func mineRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
-	// Accumulate the rewards for the miner and any included uncles
-	tmp := new(big.Int).Set(blockReward)
+// BlockRewards calculates the rewards mining a block with the given header and
+// uncles credits: the reward of the coinbase, including its share for every
+// included uncle, and the cut paid to the author reward address.
+func (d *Tppow) BlockRewards(header *types.Header, uncles []*types.Header) (reward *big.Int, author common.Address, authorReward *big.Int) {
+	if !d.config.IsUncles(header.Number) {
+		uncles = nil
+	}
+	reward, authorReward, _ = d.blockRewards(header, uncles)
+	return reward, d.config.AuthorRewardAddr, authorReward
+}
+
+// blockRewards calculates the rewards of the coinbase, the author reward address
+// and every uncle of a block.
+func (d *Tppow) blockRewards(header *types.Header, uncles []*types.Header) (*big.Int, *big.Int, []*big.Int) {
+	tmp := new(big.Int).Set(d.config.BlockReward)
+	if d.config.IsV2(header.Number) && d.config.V2BlockReward != nil {
+		tmp.Set(d.config.V2BlockReward)
+	}
 	height := header.Number.Uint64()
-	divHeight := uint64(3110400)  // 2years = 2 * 360 * 24 * 60 * 3
-	//divHeight := uint64(11)
-	num := height / divHeight
+	num := height / d.config.HalvingInterval
 	if num > uint64(50) {
 		tmp = big.NewInt(0)
 	} else {
@@ -587,32 +767,57 @@ func mineRewards(config *params.ChainConfig, state *state.StateDB, header *types
 
 	reward := new(big.Int).Set(tmp)
 	authorReward := new(big.Int).Set(reward)
-	authorReward.Mul(authorReward, big.NewInt(5))
+	authorReward.Mul(authorReward, new(big.Int).SetUint64(*d.config.AuthorRewardPercent))
 	authorReward.Div(authorReward, big.NewInt(100))
 
-	// r := new(big.Int)
-	// for _, uncle := range uncles {
-	// 	r.Add(uncle.Number, big8)
-	// 	r.Sub(r, header.Number)
-	// 	r.Mul(r, tmp)
-	// 	r.Div(r, big8)
-	// 	state.AddBalance(uncle.Coinbase, r)
+	// Uncles are rewarded by depth, the including block by a flat share per uncle
+	var uncleRewards []*big.Int
+	if d.config.IsUncles(header.Number) {
+		uncleDivisor := new(big.Int).SetUint64(d.config.UncleRewardDivisor)
+		nephewDivisor := new(big.Int).SetUint64(d.config.NephewRewardDivisor)
+
+		for _, uncle := range uncles {
+			r := new(big.Int).Add(uncle.Number, uncleDivisor)
+			r.Sub(r, header.Number)
+			if r.Sign() > 0 {
+				r.Mul(r, tmp)
+				r.Div(r, uncleDivisor)
+			} else {
+				r.SetUint64(0)
+			}
+			uncleRewards = append(uncleRewards, r)
+			reward.Add(reward, new(big.Int).Div(tmp, nephewDivisor))
+		}
+	}
+	return reward, authorReward, uncleRewards
+}
 
-	// 	r.Div(tmp, big32)
-	// 	reward.Add(reward, r)
-	// }
+func (d *Tppow) mineRewards(state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	// Accumulate the rewards for the miner and any included uncles
+	reward, authorReward, uncleRewards := d.blockRewards(header, uncles)
+	for i, r := range uncleRewards {
+		if r.Sign() > 0 {
+			state.AddBalance(uncles[i].Coinbase, r)
+		}
+	}
 	state.AddBalance(header.Coinbase, reward)
-	state.AddBalance(params.AuthorRewardAddr, authorReward)
+	state.AddBalance(d.config.AuthorRewardAddr, authorReward)
 }
 
-func (d *Tppow) calcParam(chain consensus.ChainReader, number uint64, time uint64, parent *types.Header) (*big.Int, *big.Int) {
+func (d *Tppow) calcParam(chain consensus.ChainReader, number uint64, time uint64, parent *types.Header, batch []*types.Header) (*big.Int, *big.Int) {
 	if number <= uint64(1) {
-		return initBasis, initDifficultyAlpha 
+		return initBasis, initDifficultyAlpha
+	}
+	if d.config.IsV2(new(big.Int).SetUint64(number)) {
+		return d.calcParamV2(chain, parent, batch)
 	}
-	
+
 	dAlpha := new(big.Int).Set(parent.DifficultyAlpha)
-	dBeta := new(big.Int).Set(parent.DifficultyBeta)
-	dBeta = dBeta.Div(dBeta, big.NewInt(5))
+	// Unsealed parents (e.g. generated test chains) carry no beta difficulty
+	dBeta := new(big.Int)
+	if parent.DifficultyBeta != nil {
+		dBeta.Div(parent.DifficultyBeta, big.NewInt(5))
+	}
 	basis := new(big.Int).Set(parent.Basis)
 
 	// maxBasis := new(big.Int).Set(parent.DifficultyAlpha)
@@ -629,8 +834,8 @@ func (d *Tppow) calcParam(chain consensus.ChainReader, number uint64, time uint6
 		// }
 	}
 
-	// generate a block per 20 seconds
-	if time - parent.Time > 20 {   // slower
+	// generate a block per target block time
+	if time-parent.Time > d.config.TargetBlockTime { // slower
 		alpha := new(big.Int).Set(dAlpha)
 		alpha = alpha.Mul(alpha, big.NewInt(110))
 		alpha = alpha.Div(alpha, big.NewInt(100))
@@ -638,7 +843,7 @@ func (d *Tppow) calcParam(chain consensus.ChainReader, number uint64, time uint6
 		basis = basis.Mul(basis, big.NewInt(110))
 		basis = basis.Div(basis, big.NewInt(100))
 		return basis, alpha
-	} else {   // faster
+	} else { // faster
 		alpha := new(big.Int).Set(dAlpha)
 		alpha = alpha.Mul(alpha, big.NewInt(90))
 		alpha = alpha.Div(alpha, big.NewInt(100))
@@ -650,10 +855,95 @@ func (d *Tppow) calcParam(chain consensus.ChainReader, number uint64, time uint6
 	}
 }
 
+// calcParamV2 is the TppowV2 retarget. Instead of stepping the parent's targets
+// by a fixed 10% depending on the new block's timestamp, both the basis and alpha
+// are set to their average over the last V2RetargetWindow blocks, scaled by the
+// linearly weighted moving average of those blocks' solve times against the
+// target block time (LWMA). The basis is additionally balanced against the
+// parent's first and second stage difficulty the same way as before the fork.
+func (d *Tppow) calcParamV2(chain consensus.ChainReader, parent *types.Header, batch []*types.Header) (*big.Int, *big.Int) {
+	headers := d.retargetWindow(chain, parent, batch)
+	if len(headers) < 2 {
+		return new(big.Int).Set(parent.Basis), new(big.Int).Set(parent.DifficultyAlpha)
+	}
+	var (
+		target   = d.config.TargetBlockTime
+		weighted = new(big.Int)
+		expected = new(big.Int)
+		sumAlpha = new(big.Int)
+		sumBasis = new(big.Int)
+	)
+	for i := 1; i < len(headers); i++ {
+		// Clamp solve times to dampen the effect of timestamp manipulation
+		solve := headers[i].Time - headers[i-1].Time
+		if solve > 6*target {
+			solve = 6 * target
+		}
+		weighted.Add(weighted, new(big.Int).SetUint64(uint64(i)*solve))
+		expected.Add(expected, new(big.Int).SetUint64(uint64(i)*target))
+
+		sumAlpha.Add(sumAlpha, headers[i].DifficultyAlpha)
+		sumBasis.Add(sumBasis, headers[i].Basis)
+	}
+	n := big.NewInt(int64(len(headers) - 1))
+
+	// first basis, then alpha; the first difficulty is 5 times the second.
+	dBeta := new(big.Int)
+	if parent.DifficultyBeta != nil {
+		dBeta.Div(parent.DifficultyBeta, big.NewInt(5))
+	}
+	basis := sumBasis.Div(sumBasis, n)
+	if parent.DifficultyAlpha.Cmp(dBeta) < 0 {
+		basis.Mul(basis, big.NewInt(96))
+	} else {
+		basis.Mul(basis, big.NewInt(105))
+	}
+	basis.Div(basis, big.NewInt(100))
+	basis.Mul(basis, weighted)
+	basis.Div(basis, expected)
+
+	alpha := sumAlpha.Div(sumAlpha, n)
+	alpha.Mul(alpha, weighted)
+	alpha.Div(alpha, expected)
+
+	return basis, alpha
+}
+
+// retargetWindow returns the headers taking part in the TppowV2 retarget, oldest
+// first and ending with parent: up to V2RetargetWindow solved blocks plus the
+// header preceding them. Ancestors are resolved from the batch of not yet
+// imported headers first, then from the chain. The window is shortened if
+// ancestors are unavailable or would reach the genesis block.
+func (d *Tppow) retargetWindow(chain consensus.ChainReader, parent *types.Header, batch []*types.Header) []*types.Header {
+	window := make([]*types.Header, 0, d.config.V2RetargetWindow+1)
+	for cur := parent; uint64(len(window)) < d.config.V2RetargetWindow && cur.Number.Uint64() > 1; {
+		var prev *types.Header
+		for i := len(batch) - 1; i >= 0; i-- {
+			if batch[i].Hash() == cur.ParentHash {
+				prev = batch[i]
+				break
+			}
+		}
+		if prev == nil && chain != nil {
+			prev = chain.GetHeader(cur.ParentHash, cur.Number.Uint64()-1)
+		}
+		if prev == nil {
+			break
+		}
+		window = append(window, prev)
+		cur = prev
+	}
+	// Reverse into chronological order and append the parent as the last element
+	for i, j := 0, len(window)-1; i < j; i, j = i+1, j-1 {
+		window[i], window[j] = window[j], window[i]
+	}
+	return append(window, parent)
+}
+
 // basis * (lmax) ^ 2 / (lmax - luck) ^ 2
 func (d *Tppow) calcBeta(luck *big.Int, basis *big.Int) *big.Int {
-	ta := new(big.Int).Set(maxLuck)
-	tb := new(big.Int).Set(maxLuck)
+	ta := new(big.Int).Set(d.config.MaxLuck)
+	tb := new(big.Int).Set(d.config.MaxLuck)
 	tb = tb.Sub(tb, luck)
 	res := new(big.Int).Set(basis)
 	res = res.Mul(res, ta)
@@ -669,16 +959,15 @@ func (d *Tppow) calcBeta(luck *big.Int, basis *big.Int) *big.Int {
 // }
 
 func (d *Tppow) calcDifficulty(h *types.Header) *big.Int {
-	height := h.Number.Uint64()
-	if height < difficultyAjustBlock {
+	if h.Number.Cmp(d.config.DifficultyAdjustBlock) < 0 {
 		res := new(big.Int).Set(h.Lucky)
 		return res
 	} else {
 		bmax := new(big.Int).Set(max256)
-		bmax = bmax.Div(bmax, HashScale)
+		bmax = bmax.Div(bmax, d.config.HashScale)
 		res := new(big.Int).Set(bmax)
-		res = res.Mul(res, big.NewInt(1000000))     // Adjustment coefficient
+		res = res.Mul(res, big.NewInt(1000000)) // Adjustment coefficient
 		res = res.Div(res, h.Basis)
 		return res
 	}
-}
\ No newline at end of file
+}
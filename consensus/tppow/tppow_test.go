@@ -0,0 +1,323 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppow
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/core"
+	"github.com/luck/go-luck/core/rawdb"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/core/vm"
+	"github.com/luck/go-luck/crypto"
+	"github.com/luck/go-luck/params"
+)
+
+// makeFakeChain generates a chain of n empty blocks on top of a fresh genesis,
+// returning the genesis specification and the generated blocks.
+func makeFakeChain(n int) (*core.Genesis, []*types.Block) {
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	blocks, _ := core.GenerateChain(genesis.Config, genesis.MustCommit(rawdb.NewMemoryDatabase()), NewFaker(), rawdb.NewMemoryDatabase(), n, nil)
+	return genesis, blocks
+}
+
+// Tests that a fake engine accepts generated chains which don't carry a valid
+// proof-of-work, and that the fake failer rejects the configured block.
+func TestFakeChainImport(t *testing.T) {
+	genesis, blocks := makeFakeChain(8)
+
+	tests := []struct {
+		engine *Tppow
+		failAt int
+	}{
+		{NewFaker(), -1},
+		{NewFakeDelayer(time.Millisecond), -1},
+		{NewFullFaker(), -1},
+		{NewFakeFailer(5), 4},
+	}
+	for i, tt := range tests {
+		db := rawdb.NewMemoryDatabase()
+		genesis.MustCommit(db)
+
+		chain, err := core.NewBlockChain(db, nil, genesis.Config, tt.engine, vm.Config{}, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to create chain: %v", i, err)
+		}
+		n, err := chain.InsertChain(blocks)
+		switch {
+		case tt.failAt < 0 && err != nil:
+			t.Errorf("test %d: failed to import block %d: %v", i, n, err)
+		case tt.failAt >= 0 && err == nil:
+			t.Errorf("test %d: invalid block accepted", i)
+		case tt.failAt >= 0 && n != tt.failAt:
+			t.Errorf("test %d: failure index mismatch: have %d, want %d", i, n, tt.failAt)
+		}
+		chain.Stop()
+	}
+}
+
+// Tests that a fake engine seals blocks instantly with zero nonces.
+func TestFakeSeal(t *testing.T) {
+	_, blocks := makeFakeChain(1)
+
+	results := make(chan *types.Block, 1)
+	if err := NewFaker().Seal(nil, blocks[0], results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	select {
+	case block := <-results:
+		if block.Header().FirstNonce != (types.BlockNonce{}) || block.Header().SecondNonce != (types.BlockNonce{}) {
+			t.Errorf("nonces mismatch: have %x/%x, want zero", block.Header().FirstNonce, block.Header().SecondNonce)
+		}
+		if block.Header().DifficultyBeta.Cmp(block.Header().Basis) != 0 {
+			t.Errorf("beta difficulty mismatch: have %v, want %v", block.Header().DifficultyBeta, block.Header().Basis)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealing result timeout")
+	}
+}
+
+// makeRetargetHeaders creates a linked run of headers starting at block 1, all
+// carrying the same targets and spaced by the given solve time.
+func makeRetargetHeaders(n int, solve uint64) []*types.Header {
+	headers := make([]*types.Header, n)
+	for i := range headers {
+		headers[i] = &types.Header{
+			Number:          big.NewInt(int64(i + 1)),
+			Time:            1600000000 + uint64(i)*solve,
+			Basis:           big.NewInt(1000000),
+			DifficultyAlpha: big.NewInt(1000000),
+			DifficultyBeta:  big.NewInt(1000000),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+	}
+	return headers
+}
+
+// Tests that the TppowV2 weighted retarget keeps the targets steady on schedule
+// and eases them when blocks are slow, while the rules before the fork still
+// step by a fixed 10%.
+func TestCalcParamV2(t *testing.T) {
+	tppow := NewFakerWithConfig(&params.TppowConfig{V2Block: big.NewInt(10), V2RetargetWindow: 5})
+
+	tests := []struct {
+		solve uint64
+		alpha int64
+	}{
+		{20, 1000000},  // on target, alpha unchanged
+		{40, 2000000},  // twice as slow, alpha doubled
+		{200, 6000000}, // solve times clamped to 6 times the target
+	}
+	for i, tt := range tests {
+		headers := makeRetargetHeaders(20, tt.solve)
+		parent := headers[len(headers)-1]
+
+		basis, alpha := tppow.calcParam(nil, parent.Number.Uint64()+1, parent.Time+tt.solve, parent, headers[:len(headers)-1])
+		if alpha.Int64() != tt.alpha {
+			t.Errorf("test %d: alpha mismatch: have %v, want %v", i, alpha, tt.alpha)
+		}
+		if want := tt.alpha * 105 / 100; basis.Int64() != want {
+			t.Errorf("test %d: basis mismatch: have %v, want %v", i, basis, want)
+		}
+	}
+	// Blocks before the fork are retargeted by the original rules
+	headers := makeRetargetHeaders(5, 40)
+	parent := headers[len(headers)-1]
+	if _, alpha := tppow.calcParam(nil, parent.Number.Uint64()+1, parent.Time+40, parent, headers); alpha.Int64() != 1100000 {
+		t.Errorf("pre-fork alpha mismatch: have %v, want %v", alpha, 1100000)
+	}
+}
+
+// Tests that the Argon2 memory size switches at the TppowV2 fork block.
+func TestArgon2MemoryV2(t *testing.T) {
+	tppow := NewFakerWithConfig(&params.TppowConfig{V2Block: big.NewInt(10), V2Argon2Memory: 1024})
+
+	data, salt := []byte("data"), []byte("saltsalt")
+	if have, want := tppow.argon2(&types.Header{Number: big.NewInt(9)}, data, salt), crypto.Argon2Hash(data, salt); !bytes.Equal(have, want) {
+		t.Errorf("pre-fork hash mismatch: have %x, want %x", have, want)
+	}
+	if have, want := tppow.argon2(&types.Header{Number: big.NewInt(10)}, data, salt), crypto.Argon2HashWithMemory(data, salt, 1024); !bytes.Equal(have, want) {
+		t.Errorf("post-fork hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that uncles are only included and rewarded once the uncle policy is
+// active.
+func TestUncleRewards(t *testing.T) {
+	var (
+		miner  = common.Address{0x01}
+		uncler = common.Address{0x02}
+		reward = params.DefaultTppowConfig.BlockReward
+	)
+	tests := []struct {
+		uncleBlock *big.Int
+		uncles     int
+		uncle      *big.Int // uncle coinbase balance
+		miner      *big.Int // miner coinbase balance
+	}{
+		// Policy inactive, uncles are dropped and not rewarded
+		{nil, 0, new(big.Int), new(big.Int).Mul(reward, big.NewInt(2))},
+		// Policy active, the uncle of depth 1 earns 7/8 and the nephew 1/32 extra
+		{
+			common.Big0, 1,
+			new(big.Int).Div(new(big.Int).Mul(reward, big.NewInt(7)), big.NewInt(8)),
+			new(big.Int).Add(new(big.Int).Mul(reward, big.NewInt(2)), new(big.Int).Div(reward, big.NewInt(32))),
+		},
+	}
+	for i, tt := range tests {
+		engine := NewFakerWithConfig(&params.TppowConfig{UncleBlock: tt.uncleBlock})
+
+		db := rawdb.NewMemoryDatabase()
+		genesis := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+		side, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 1, func(i int, b *core.BlockGen) {
+			b.SetCoinbase(uncler)
+		})
+		blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 2, func(i int, b *core.BlockGen) {
+			b.SetCoinbase(miner)
+			if i == 1 {
+				b.AddUncle(side[0].Header())
+			}
+		})
+		if have := len(blocks[1].Uncles()); have != tt.uncles {
+			t.Errorf("test %d: uncle count mismatch: have %d, want %d", i, have, tt.uncles)
+		}
+		chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to create chain: %v", i, err)
+		}
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("test %d: failed to import chain: %v", i, err)
+		}
+		state, _ := chain.State()
+		if have := state.GetBalance(uncler); have.Cmp(tt.uncle) != 0 {
+			t.Errorf("test %d: uncle balance mismatch: have %v, want %v", i, have, tt.uncle)
+		}
+		if have := state.GetBalance(miner); have.Cmp(tt.miner) != 0 {
+			t.Errorf("test %d: miner balance mismatch: have %v, want %v", i, have, tt.miner)
+		}
+		// The reported rewards of the uncle including block match the credited ones
+		want := new(big.Int).Sub(tt.miner, reward)
+		if have, _, _ := engine.BlockRewards(blocks[1].Header(), blocks[1].Uncles()); have.Cmp(want) != 0 {
+			t.Errorf("test %d: reported reward mismatch: have %v, want %v", i, have, want)
+		}
+		chain.Stop()
+	}
+}
+
+// Tests that seal verification failures report the failed check along with the
+// offending and expected values.
+func TestVerifySealErrors(t *testing.T) {
+	tppow := NewFaker()
+	tppow.mode = ModeNormal
+
+	header := &types.Header{
+		Number:          big.NewInt(1),
+		Basis:           big.NewInt(1000),
+		DifficultyAlpha: new(big.Int).Set(max256),
+		Lucky:           new(big.Int).Set(params.DefaultTppowConfig.MaxLuck),
+	}
+	err := tppow.VerifySeal(nil, header)
+	if !errors.Is(err, ErrLuckMismatch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrLuckMismatch)
+	}
+	sealErr := err.(*SealError)
+	if sealErr.Have.Cmp(header.Lucky) != 0 {
+		t.Errorf("luck mismatch: have %v, want %v", sealErr.Have, header.Lucky)
+	}
+	if want := tppow.calcLuck(header, 0); sealErr.Want.Cmp(want) != 0 {
+		t.Errorf("expected luck mismatch: have %v, want %v", sealErr.Want, want)
+	}
+	header.DifficultyAlpha = new(big.Int)
+	if err := tppow.VerifySeal(nil, header); !errors.Is(err, ErrAlphaTarget) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrAlphaTarget)
+	}
+}
+
+// Tests that successfully verified seals are cached and not verified again,
+// while failed ones are rejected every time.
+func TestVerifySealCache(t *testing.T) {
+	tppow := NewFaker()
+	tppow.mode = ModeNormal
+
+	// Assemble a header that passes both stages with zero nonces
+	header := &types.Header{
+		Number:          big.NewInt(1),
+		Basis:           new(big.Int).Lsh(common.Big1, 200),
+		DifficultyAlpha: new(big.Int).Set(max256),
+	}
+	header.Lucky = tppow.calcLuck(header, 0)
+	header.DifficultyBeta = tppow.calcBeta(header.Lucky, header.Basis)
+	header.Difficulty = tppow.calcDifficulty(header)
+
+	if err := tppow.VerifySeal(nil, header); err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	if !tppow.seals.Contains(header.Hash()) {
+		t.Fatalf("verified seal not cached")
+	}
+	// Cache hits must not wait for a verifier slot
+	for i := 0; i < cap(tppow.verifiers); i++ {
+		tppow.verifiers <- struct{}{}
+	}
+	if err := tppow.VerifySeal(nil, header); err != nil {
+		t.Errorf("cached seal rejected: %v", err)
+	}
+	for i := 0; i < cap(tppow.verifiers); i++ {
+		<-tppow.verifiers
+	}
+	// Tampered headers hash differently and must be verified in full
+	header.Difficulty = new(big.Int).Add(header.Difficulty, common.Big1)
+	if err := tppow.VerifySeal(nil, header); !errors.Is(err, ErrDifficultyMismatch) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrDifficultyMismatch)
+	}
+	if tppow.seals.Contains(header.Hash()) {
+		t.Errorf("invalid seal cached")
+	}
+}
+
+// Tests that the expected seal time estimated from benchmarked hashrates scales
+// with both the hashrates and the difficulty.
+func TestExpectedSealTime(t *testing.T) {
+	config := params.DefaultTppowConfig.WithDefaults()
+	result := &BenchmarkResult{Threads: 1, LuckRate: 10, CalcRate: 10, BlockRate: 10, config: config}
+
+	alpha, basis := new(big.Int).Set(initDifficultyAlpha), new(big.Int).Set(initBasis)
+	base := result.ExpectedSealTime(alpha, basis)
+	if base <= 0 {
+		t.Fatalf("non-positive seal time: %v", base)
+	}
+	faster := &BenchmarkResult{Threads: 1, LuckRate: 20, CalcRate: 20, BlockRate: 20, config: config}
+	if have := faster.ExpectedSealTime(alpha, basis); have*2 < base-time.Millisecond || have*2 > base+time.Millisecond {
+		t.Errorf("doubled hashrate: have %v, want %v", have, base/2)
+	}
+	easier := result.ExpectedSealTime(new(big.Int).Lsh(alpha, 1), new(big.Int).Lsh(basis, 1))
+	if easier >= base {
+		t.Errorf("lower difficulty not faster: have %v, base %v", easier, base)
+	}
+	if have := result.ExpectedSealTime(new(big.Int), basis); have != time.Duration(math.MaxInt64) {
+		t.Errorf("zero alpha: have %v, want unbounded", have)
+	}
+}
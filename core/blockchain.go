@@ -1808,6 +1808,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 			stats.queued++
 		}
 	}
+	// Any other error is a block failing validation in the middle of the batch
+	if block != nil && err != nil && err != consensus.ErrFutureBlock && err != consensus.ErrUnknownAncestor &&
+		err != consensus.ErrPrunedAncestor && err != ErrKnownBlock {
+		bc.reportBlock(block, nil, err)
+	}
 	stats.ignored += it.remaining()
 
 	return it.index, err
@@ -2116,26 +2121,40 @@ func (bc *BlockChain) update() {
 	}
 }
 
+// badBlock is a block rejected during import, along with the reason why.
+type badBlock struct {
+	block  *types.Block
+	reason error
+}
+
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
 	for _, hash := range bc.badBlocks.Keys() {
 		if blk, exist := bc.badBlocks.Peek(hash); exist {
-			block := blk.(*types.Block)
-			blocks = append(blocks, block)
+			blocks = append(blocks, blk.(*badBlock).block)
 		}
 	}
 	return blocks
 }
 
+// BadBlockReason returns the error a recently seen bad block was rejected with,
+// or nil if the block is not known to be bad.
+func (bc *BlockChain) BadBlockReason(hash common.Hash) error {
+	if blk, exist := bc.badBlocks.Peek(hash); exist {
+		return blk.(*badBlock).reason
+	}
+	return nil
+}
+
 // addBadBlock adds a bad block to the bad-block LRU cache
-func (bc *BlockChain) addBadBlock(block *types.Block) {
-	bc.badBlocks.Add(block.Hash(), block)
+func (bc *BlockChain) addBadBlock(block *types.Block, reason error) {
+	bc.badBlocks.Add(block.Hash(), &badBlock{block: block, reason: reason})
 }
 
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
-	bc.addBadBlock(block)
+	bc.addBadBlock(block, err)
 
 	var receiptString string
 	for i, receipt := range receipts {
@@ -2151,9 +2170,9 @@ Number: %v
 Hash: 0x%x
 %v
 
-Error: %v
+Error: %v (%T)
 ##############################
-`, bc.chainConfig, block.Number(), block.Hash(), receiptString, err))
+`, bc.chainConfig, block.Number(), block.Hash(), receiptString, err, err))
 }
 
 // InsertHeaderChain attempts to insert the given header chain in to the local
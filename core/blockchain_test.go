@@ -556,6 +556,11 @@ func testInsertNonceError(t *testing.T, full bool) {
 
 			blockchain.engine = ethash.NewFakeFailer(failNum)
 			failRes, err = blockchain.InsertChain(blocks)
+
+			// Check that the failing block is reported as bad along with its error
+			if reason := blockchain.BadBlockReason(blocks[failAt].Hash()); reason != err {
+				t.Errorf("test %d: bad block reason mismatch: have %v, want %v", i, reason, err)
+			}
 		} else {
 			headers := makeHeaderChain(blockchain.CurrentHeader(), i, ethash.NewFaker(), db, 0)
 
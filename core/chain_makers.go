@@ -212,6 +212,10 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 			gen(i, b)
 		}
 		if b.engine != nil {
+			// Fill in any parameters the engine derives from the parent header
+			if p, ok := b.engine.(parentPreparer); ok {
+				p.PrepareFromParent(b.header, parent.Header())
+			}
 			// Finalize and seal the block
 			block, _ := b.engine.FinalizeAndAssemble(chainreader, b.header, statedb, b.txs, b.uncles, b.receipts)
 
@@ -240,6 +244,12 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 	return blocks, receipts
 }
 
+// parentPreparer is implemented by consensus engines whose headers carry extra
+// difficulty parameters derived from the parent header.
+type parentPreparer interface {
+	PrepareFromParent(header *types.Header, parent *types.Header)
+}
+
 func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.StateDB, engine consensus.Engine) *types.Header {
 	var time uint64
 	if parent.Time() == 0 {
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/luck/go-luck/common"
@@ -48,6 +49,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		CanTransfer: CanTransfer,
 		Transfer:    Transfer,
 		GetHash:     GetHashFn(header, chain),
+		VerifySeal:  getVerifySealFn(chain),
 		Origin:      msg.From(),
 		Coinbase:    beneficiary,
 		BlockNumber: new(big.Int).Set(header.Number),
@@ -58,6 +60,31 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 	}
 }
 
+// errNoSealVerifier is returned when verifying a seal on a chain whose consensus
+// engine cannot verify seals outside of header verification.
+var errNoSealVerifier = errors.New("consensus engine cannot verify seals")
+
+// sealVerifier is implemented by consensus engines able to back the seal
+// verification precompile.
+type sealVerifier interface {
+	VerifyHeaderSeal(header *types.Header) error
+}
+
+// getVerifySealFn returns a VerifySealFunc which checks seals with the consensus
+// engine of the chain, rejecting all of them if there is no chain or its engine
+// cannot verify seals on its own.
+func getVerifySealFn(chain ChainContext) vm.VerifySealFunc {
+	return func(header *types.Header) error {
+		if chain == nil {
+			return errNoSealVerifier
+		}
+		if engine, ok := chain.Engine().(sealVerifier); ok {
+			return engine.VerifyHeaderSeal(header)
+		}
+		return errNoSealVerifier
+	}
+}
+
 // GetHashFn returns a GetHashFunc which retrieves header hashes by number
 func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash {
 	// Cache will initially contain [refHash.parent],
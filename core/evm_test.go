@@ -0,0 +1,66 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/core/types"
+)
+
+// sealTestChain is a chain context backed by a bare consensus engine.
+type sealTestChain struct {
+	engine consensus.Engine
+}
+
+func (c *sealTestChain) Engine() consensus.Engine                    { return c.engine }
+func (c *sealTestChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+// sealTestEngine is a consensus engine able to verify seals on its own.
+type sealTestEngine struct {
+	consensus.Engine
+	err error
+}
+
+func (e *sealTestEngine) VerifyHeaderSeal(header *types.Header) error { return e.err }
+
+// Tests that the seal verification precompile is only backed by engines able to
+// verify seals, and that it doesn't crash without a chain to verify against.
+func TestVerifySealFn(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(1)}
+
+	if err := getVerifySealFn(nil)(header); err != errNoSealVerifier {
+		t.Errorf("nil chain: error mismatch: have %v, want %v", err, errNoSealVerifier)
+	}
+	if err := getVerifySealFn(&sealTestChain{})(header); err != errNoSealVerifier {
+		t.Errorf("nil engine: error mismatch: have %v, want %v", err, errNoSealVerifier)
+	}
+	if err := getVerifySealFn(&sealTestChain{ethash.NewFaker()})(header); err != errNoSealVerifier {
+		t.Errorf("ethash: error mismatch: have %v, want %v", err, errNoSealVerifier)
+	}
+	if err := getVerifySealFn(&sealTestChain{&sealTestEngine{}})(header); err != nil {
+		t.Errorf("verifier: valid seal rejected: %v", err)
+	}
+	fail := &sealTestEngine{err: errNoSealVerifier}
+	if err := getVerifySealFn(&sealTestChain{fail})(header); err != fail.err {
+		t.Errorf("verifier: error mismatch: have %v, want %v", err, fail.err)
+	}
+}
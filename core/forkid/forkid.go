@@ -223,6 +223,14 @@ func gatherForks(config *params.ChainConfig) []uint64 {
 			forks = append(forks, rule.Uint64())
 		}
 	}
+	// Consensus engine rule changes are scheduled outside the top level config
+	if config.Tppow != nil {
+		for _, rule := range []*big.Int{config.Tppow.V2Block, config.Tppow.UncleBlock, config.Tppow.PrecompileBlock} {
+			if rule != nil {
+				forks = append(forks, rule.Uint64())
+			}
+		}
+	}
 	// Sort the fork block numbers to permit chronologival XOR
 	for i := 0; i < len(forks); i++ {
 		for j := i + 1; j < len(forks); j++ {
@@ -19,6 +19,8 @@ package forkid
 import (
 	"bytes"
 	"math"
+	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/luck/go-luck/common"
@@ -225,3 +227,28 @@ func TestEncoding(t *testing.T) {
 		}
 	}
 }
+
+// Tests that consensus engine rule changes scheduled inside the Tppow config are
+// part of the fork ID.
+func TestGatherTppowForks(t *testing.T) {
+	config := *params.AllEthashProtocolChanges
+	config.Tppow = &params.TppowConfig{V2Block: big.NewInt(1000), UncleBlock: big.NewInt(500), PrecompileBlock: big.NewInt(750)}
+
+	forks := gatherForks(&config)
+	if want := []uint64{500, 750, 1000}; !reflect.DeepEqual(forks, want) {
+		t.Errorf("fork list mismatch: have %v, want %v", forks, want)
+	}
+	// Nodes disagreeing on the precompile activation must advertise different
+	// fork IDs once past it, and be rejected by each other's filters
+	other := config
+	other.Tppow = &params.TppowConfig{V2Block: big.NewInt(1000), UncleBlock: big.NewInt(500), PrecompileBlock: big.NewInt(800)}
+
+	genesis := params.MainnetGenesisHash
+	if have, want := newID(&config, genesis, 760), newID(&other, genesis, 760); have == want {
+		t.Errorf("fork id unaffected by precompile block: %v", have)
+	}
+	filter := newFilter(&other, genesis, func() uint64 { return 760 })
+	if err := filter(newID(&config, genesis, 760)); err == nil {
+		t.Errorf("fork id with mismatching precompile block accepted")
+	}
+}
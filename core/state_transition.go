@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/core/vm"
 	"github.com/luck/go-luck/log"
 	"github.com/luck/go-luck/params"
@@ -73,6 +74,7 @@ type Message interface {
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	AccessList() types.AccessList
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
@@ -112,6 +114,18 @@ func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 boo
 	return gas, nil
 }
 
+// AccessListIntrinsicGas computes the 'intrinsic gas' for a message with the
+// given data and access list, the latter priced per address and storage key.
+func AccessListIntrinsicGas(data []byte, accessList types.AccessList, contractCreation, isHomestead bool, isEIP2028 bool) (uint64, error) {
+	gas, err := IntrinsicGas(data, contractCreation, isHomestead, isEIP2028)
+	if err != nil || accessList == nil {
+		return gas, err
+	}
+	gas += uint64(len(accessList)) * params.TxAccessListAddressGas
+	gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	return gas, nil
+}
+
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
@@ -195,7 +209,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	contractCreation := msg.To() == nil
 
 	// Pay intrinsic gas
-	gas, err := IntrinsicGas(st.data, contractCreation, homestead, istanbul)
+	gas, err := AccessListIntrinsicGas(st.data, msg.AccessList(), contractCreation, homestead, istanbul)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -210,6 +224,11 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		// error.
 		vmerr error
 	)
+	// Warm up the sender, destination, precompiles and the optional access
+	// list once Berlin is active
+	if rules := evm.ChainConfig().Rules(evm.BlockNumber); rules.IsBerlin {
+		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
+	}
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
 	} else {
@@ -40,6 +40,43 @@ type devNull struct{}
 func (*devNull) Write(p []byte) (n int, err error) { return len(p), nil }
 func (*devNull) Close() error                      { return nil }
 
+// journalPrivateTx is the journal entry of a private transaction, storing the
+// block number it is withheld from the network until alongside it.
+type journalPrivateTx struct {
+	Expiry uint64
+	Tx     *types.Transaction
+}
+
+// decodeJournalEntry decodes a single journal entry, which is either a plain
+// transaction or a private one wrapped together with its expiry block. Plain
+// entries are reported with a zero expiry.
+func decodeJournalEntry(raw rlp.RawValue) (*types.Transaction, uint64, error) {
+	// Legacy transactions are lists too, but never of two items
+	if content, _, err := rlp.SplitList(raw); err == nil {
+		if n, err := rlp.CountValues(content); err == nil && n == 2 {
+			entry := new(journalPrivateTx)
+			if err := rlp.DecodeBytes(raw, entry); err != nil {
+				return nil, 0, err
+			}
+			return entry.Tx, entry.Expiry, nil
+		}
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, 0, err
+	}
+	return tx, 0, nil
+}
+
+// encodeJournalEntry writes a single transaction into the journal, wrapping it
+// together with its expiry block if it's a private one.
+func encodeJournalEntry(w io.Writer, tx *types.Transaction, private map[common.Hash]uint64) error {
+	if expiry, ok := private[tx.Hash()]; ok {
+		return rlp.Encode(w, &journalPrivateTx{Expiry: expiry, Tx: tx})
+	}
+	return rlp.Encode(w, tx)
+}
+
 // txJournal is a rotating log of transactions with the aim of storing locally
 // created transactions to allow non-executed ones to survive node restarts.
 type txJournal struct {
@@ -55,8 +92,9 @@ func newTxJournal(path string) *txJournal {
 }
 
 // load parses a transaction journal dump from disk, loading its contents into
-// the specified pool.
-func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
+// the specified pool. Private transactions are injected one by one along with
+// their expiry block, all others in batches.
+func (journal *txJournal) load(add func([]*types.Transaction) []error, addPrivate func(*types.Transaction, uint64) error) error {
 	// Skip the parsing if the journal file doesn't exist at all
 	if _, err := os.Stat(journal.path); os.IsNotExist(err) {
 		return nil
@@ -93,8 +131,15 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 	)
 	for {
 		// Parse the next transaction and terminate on error
-		tx := new(types.Transaction)
-		if err = stream.Decode(tx); err != nil {
+		var (
+			raw    rlp.RawValue
+			tx     *types.Transaction
+			expiry uint64
+		)
+		if err = stream.Decode(&raw); err == nil {
+			tx, expiry, err = decodeJournalEntry(raw)
+		}
+		if err != nil {
 			if err != io.EOF {
 				failure = err
 			}
@@ -106,6 +151,18 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 		// New transaction parsed, queue up for later, import if threshold is reached
 		total++
 
+		if expiry != 0 {
+			// Flush any preceding transactions to retain the journal order
+			if batch.Len() > 0 {
+				loadBatch(batch)
+				batch = batch[:0]
+			}
+			if err := addPrivate(tx, expiry); err != nil {
+				log.Debug("Failed to add journaled private transaction", "err", err)
+				dropped++
+			}
+			continue
+		}
 		if batch = append(batch, tx); batch.Len() > 1024 {
 			loadBatch(batch)
 			batch = batch[:0]
@@ -116,12 +173,13 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 	return failure
 }
 
-// insert adds the specified transaction to the local disk journal.
-func (journal *txJournal) insert(tx *types.Transaction) error {
+// insert adds the specified transaction to the local disk journal, along with
+// its expiry block if it's in the private set.
+func (journal *txJournal) insert(tx *types.Transaction, private map[common.Hash]uint64) error {
 	if journal.writer == nil {
 		return errNoActiveJournal
 	}
-	if err := rlp.Encode(journal.writer, tx); err != nil {
+	if err := encodeJournalEntry(journal.writer, tx, private); err != nil {
 		return err
 	}
 	return nil
@@ -129,7 +187,7 @@ func (journal *txJournal) insert(tx *types.Transaction) error {
 
 // rotate regenerates the transaction journal based on the current contents of
 // the transaction pool.
-func (journal *txJournal) rotate(all map[common.Address]types.Transactions) error {
+func (journal *txJournal) rotate(all map[common.Address]types.Transactions, private map[common.Hash]uint64) error {
 	// Close the current journal (if any is open)
 	if journal.writer != nil {
 		if err := journal.writer.Close(); err != nil {
@@ -145,7 +203,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 	journaled := 0
 	for _, txs := range all {
 		for _, tx := range txs {
-			if err = rlp.Encode(replacement, tx); err != nil {
+			if err = encodeJournalEntry(replacement, tx, private); err != nil {
 				replacement.Close()
 				return err
 			}
@@ -473,7 +473,27 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 	if local.containsTx(tx) {
 		return false
 	}
-	// Discard stale price points if found at the heap start
+	// Check if the transaction is underpriced or not
+	cheapest := l.head()
+	if cheapest == nil {
+		log.Error("Pricing query for empty pool") // This cannot happen, print to catch programming errors
+		return false
+	}
+	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
+}
+
+// Cheapest returns the gas price of the lowest priced transaction currently being
+// tracked, or nil if the list is empty.
+func (l *txPricedList) Cheapest() *big.Int {
+	if cheapest := l.head(); cheapest != nil {
+		return cheapest.GasPrice()
+	}
+	return nil
+}
+
+// head discards any stale price points from the start of the heap and returns
+// the cheapest live transaction, or nil if none is left.
+func (l *txPricedList) head() *types.Transaction {
 	for len(*l.items) > 0 {
 		head := []*types.Transaction(*l.items)[0]
 		if l.all.Get(head.Hash()) == nil {
@@ -481,15 +501,9 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 			heap.Pop(l.items)
 			continue
 		}
-		break
+		return head
 	}
-	// Check if the transaction is underpriced or not
-	if len(*l.items) == 0 {
-		log.Error("Pricing query for empty pool") // This cannot happen, print to catch programming errors
-		return false
-	}
-	cheapest := []*types.Transaction(*l.items)[0]
-	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
+	return nil
 }
 
 // Discard finds a number of most underpriced transactions, removes them from the
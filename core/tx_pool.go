@@ -18,6 +18,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -91,6 +92,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrTxTypeNotSupported is returned if a transaction of a typed envelope is
+	// received before the fork enabling it.
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
 )
 
 var (
@@ -133,6 +138,33 @@ const (
 	TxStatusIncluded
 )
 
+// QueueReason is the reason a transaction is held in the non-executable queue
+// instead of being promoted into the pending set.
+type QueueReason uint
+
+const (
+	QueueReasonNone       QueueReason = iota // Transaction is due for promotion in the next reorg run
+	QueueReasonNonceGap                      // A lower nonce of the sender is missing from the pool
+	QueueReasonQuota                         // Sender already holds its quota of pending transactions
+	QueueReasonPriceFloor                    // Transaction (and its dependents) pay less than the fee floor
+)
+
+// String implements fmt.Stringer.
+func (r QueueReason) String() string {
+	switch r {
+	case QueueReasonNone:
+		return "awaitingPromotion"
+	case QueueReasonNonceGap:
+		return "nonceGap"
+	case QueueReasonQuota:
+		return "accountQuota"
+	case QueueReasonPriceFloor:
+		return "priceFloor"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint(r))
+	}
+}
+
 // blockChain provides the state of blockchain and current gas limit to do
 // some pre checks in tx pool and event subscribers.
 type blockChain interface {
@@ -150,15 +182,25 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	Snapshot      string        // Snapshot of remote transactions to survive node restarts (empty = disabled)
+	Resnapshot    time.Duration // Time interval to regenerate the remote transaction snapshot
+	SnapshotLimit uint64        // Maximum number of transactions to snapshot or export
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
-	AccountSlots uint64 // Number of executable transaction slots guaranteed per account
-	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
-	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
-	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	FloorThreshold uint64 // Pending pool fill percentage above which the promotion fee floor starts rising (0 = disabled)
+	FloorBump      uint64 // Percentage above the cheapest pooled price the fee floor reaches on a full pending pool
+
+	AccountSlots   uint64 // Number of executable transaction slots guaranteed per account
+	AccountPending uint64 // Maximum number of executable transaction slots permitted per account (0 = unlimited)
+	GlobalSlots    uint64 // Maximum number of executable transaction slots for all accounts
+	AccountQueue   uint64 // Maximum number of non-executable transaction slots permitted per account
+	GlobalQueue    uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	PrivateBlocks uint64 // Number of blocks private transactions are withheld from network propagation
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -167,15 +209,24 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	Resnapshot:    10 * time.Minute,
+	SnapshotLimit: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
-	AccountSlots: 16,
-	GlobalSlots:  4096,
-	AccountQueue: 64,
-	GlobalQueue:  1024,
+	FloorThreshold: 80,
+	FloorBump:      10,
+
+	AccountSlots:   16,
+	AccountPending: 1024,
+	GlobalSlots:    4096,
+	AccountQueue:   64,
+	GlobalQueue:    1024,
 
 	Lifetime: 3 * time.Hour,
+
+	PrivateBlocks: 25,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -186,6 +237,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.Resnapshot < time.Second {
+		log.Warn("Sanitizing invalid txpool snapshot time", "provided", conf.Resnapshot, "updated", time.Second)
+		conf.Resnapshot = time.Second
+	}
+	if conf.SnapshotLimit < 1 {
+		log.Warn("Sanitizing invalid txpool snapshot limit", "provided", conf.SnapshotLimit, "updated", DefaultTxPoolConfig.SnapshotLimit)
+		conf.SnapshotLimit = DefaultTxPoolConfig.SnapshotLimit
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -198,6 +257,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool account slots", "provided", conf.AccountSlots, "updated", DefaultTxPoolConfig.AccountSlots)
 		conf.AccountSlots = DefaultTxPoolConfig.AccountSlots
 	}
+	if conf.FloorThreshold > 100 {
+		log.Warn("Sanitizing invalid txpool floor threshold", "provided", conf.FloorThreshold, "updated", DefaultTxPoolConfig.FloorThreshold)
+		conf.FloorThreshold = DefaultTxPoolConfig.FloorThreshold
+	}
+	if conf.AccountPending != 0 && conf.AccountPending < conf.AccountSlots {
+		log.Warn("Sanitizing invalid txpool account pending quota", "provided", conf.AccountPending, "updated", conf.AccountSlots)
+		conf.AccountPending = conf.AccountSlots
+	}
 	if conf.GlobalSlots < 1 {
 		log.Warn("Sanitizing invalid txpool global slots", "provided", conf.GlobalSlots, "updated", DefaultTxPoolConfig.GlobalSlots)
 		conf.GlobalSlots = DefaultTxPoolConfig.GlobalSlots
@@ -214,6 +281,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.PrivateBlocks < 1 {
+		log.Warn("Sanitizing invalid txpool private blocks", "provided", conf.PrivateBlocks, "updated", DefaultTxPoolConfig.PrivateBlocks)
+		conf.PrivateBlocks = DefaultTxPoolConfig.PrivateBlocks
+	}
 	return conf
 }
 
@@ -235,13 +306,17 @@ type TxPool struct {
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whforter we are in the istanbul stage.
+	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals  *accountSet            // Set of local transaction to exempt from eviction rules
+	private map[common.Hash]uint64 // Private transactions and the block they are withheld until
+	journal *txJournal             // Journal of local transaction to back up to disk
+
+	snapshot *txSnapshot // Snapshot of remote transactions to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -274,10 +349,11 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:          config,
 		chainconfig:     chainconfig,
 		chain:           chain,
-		signer:          types.NewEIP155Signer(chainconfig.ChainID),
+		signer:          types.LatestSigner(chainconfig),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
+		private:         make(map[common.Hash]uint64),
 		all:             newTxLookup(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
@@ -303,13 +379,21 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 
-		if err := pool.journal.load(pool.AddLocals); err != nil {
+		if err := pool.journal.load(pool.AddLocals, pool.addPrivate); err != nil {
 			log.Warn("Failed to load transaction journal", "err", err)
 		}
-		if err := pool.journal.rotate(pool.local()); err != nil {
+		if err := pool.journal.rotate(pool.local(), pool.private); err != nil {
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transaction snapshotting is enabled, restore the last one from disk
+	if config.Snapshot != "" {
+		pool.snapshot = newTxSnapshot(config.Snapshot)
+
+		if err := pool.snapshot.load(pool.AddRemotesSync); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -328,15 +412,17 @@ func (pool *TxPool) loop() {
 	var (
 		prevPending, prevQueued, prevStales int
 		// Start the stats reporting and transaction eviction tickers
-		report  = time.NewTicker(statsReportInterval)
-		evict   = time.NewTicker(evictionInterval)
-		journal = time.NewTicker(pool.config.Rejournal)
+		report   = time.NewTicker(statsReportInterval)
+		evict    = time.NewTicker(evictionInterval)
+		journal  = time.NewTicker(pool.config.Rejournal)
+		snapshot = time.NewTicker(pool.config.Resnapshot)
 		// Track the previous head headers for transaction reorgs
 		head = pool.chain.CurrentBlock()
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
+	defer snapshot.Stop()
 
 	for {
 		select {
@@ -385,11 +471,17 @@ func (pool *TxPool) loop() {
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
-				if err := pool.journal.rotate(pool.local()); err != nil {
+				if err := pool.journal.rotate(pool.local(), pool.private); err != nil {
 					log.Warn("Failed to rotate local tx journal", "err", err)
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle remote transaction snapshot regeneration
+		case <-snapshot.C:
+			if pool.snapshot != nil {
+				pool.saveSnapshot()
+			}
 		}
 	}
 }
@@ -403,6 +495,9 @@ func (pool *TxPool) Stop() {
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
 
+	if pool.snapshot != nil {
+		pool.saveSnapshot()
+	}
 	if pool.journal != nil {
 		pool.journal.close()
 	}
@@ -523,9 +618,78 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// Export retrieves the transactions of the pool worth moving to another node, up
+// to the configured snapshot limit. Private transactions are never exported.
+func (pool *TxPool) Export() types.Transactions {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.export(true)
+}
+
+// saveSnapshot regenerates the snapshot of remote transactions on disk. Local
+// ones are skipped as the journal already takes care of them.
+func (pool *TxPool) saveSnapshot() {
+	pool.mu.Lock()
+	txs := pool.export(false)
+	pool.mu.Unlock()
+
+	if err := pool.snapshot.save(txs); err != nil {
+		log.Warn("Failed to save transaction pool snapshot", "err", err)
+	}
+}
+
+// export gathers up to the configured snapshot limit of non-private transactions,
+// optionally including the local ones. Executable transactions are collected first
+// in the order the miner would include them, followed by the queued ones, so that
+// the most valuable part of the pool is kept if it needs to be truncated.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) export(locals bool) types.Transactions {
+	var (
+		limit = int(pool.config.SnapshotLimit)
+		txs   = make(types.Transactions, 0, limit)
+	)
+	exportable := func(addr common.Address, tx *types.Transaction) bool {
+		if _, ok := pool.private[tx.Hash()]; ok {
+			return false
+		}
+		return locals || !pool.locals.contains(addr)
+	}
+	pending := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		pending[addr] = list.Flatten()
+	}
+	ordered := types.NewTransactionsByPriceAndNonce(pool.signer, pending)
+	for tx := ordered.Peek(); tx != nil && len(txs) < limit; tx = ordered.Peek() {
+		addr, _ := types.Sender(pool.signer, tx) // already validated
+		if !exportable(addr, tx) {
+			ordered.Pop() // Subsequent ones would be gapped on import
+			continue
+		}
+		txs = append(txs, tx)
+		ordered.Shift()
+	}
+	for addr, list := range pool.queue {
+		for _, tx := range list.Flatten() {
+			if len(txs) >= limit {
+				return txs
+			}
+			if exportable(addr, tx) {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	return txs
+}
+
 // validateTx checks whforter a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Accept only legacy transactions until EIP-2718/2930 activates.
+	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
@@ -559,7 +723,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := AccessListIntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -687,7 +851,7 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 	if pool.journal == nil || !pool.locals.contains(from) {
 		return
 	}
-	if err := pool.journal.insert(tx); err != nil {
+	if err := pool.journal.insert(tx, pool.private); err != nil {
 		log.Warn("Failed to journal local transaction", "err", err)
 	}
 }
@@ -750,6 +914,52 @@ func (pool *TxPool) AddLocal(tx *types.Transaction) error {
 	return errs[0]
 }
 
+// AddPrivate enqueues a single local transaction into the pool if it is valid,
+// withholding it from network propagation for the configured number of blocks.
+// Afterwards it is released to be gossiped like any other transaction.
+func (pool *TxPool) AddPrivate(tx *types.Transaction) error {
+	return pool.addPrivate(tx, pool.chain.CurrentBlock().NumberU64()+pool.config.PrivateBlocks)
+}
+
+// addPrivate enqueues a single local transaction into the pool if it is valid,
+// marking it private until the chain reaches the given block number.
+func (pool *TxPool) addPrivate(tx *types.Transaction, expiry uint64) error {
+	// If the transaction is known, it might have already been propagated
+	hash := tx.Hash()
+	if pool.all.Get(hash) != nil {
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	types.Sender(pool.signer, tx) // cache the sender before obtaining the lock
+
+	// Mark the transaction private before it can surface in any event
+	pool.mu.Lock()
+	pool.private[hash] = expiry
+	_, err := pool.add(tx, !pool.config.NoLocals)
+	if err != nil {
+		delete(pool.private, hash)
+	}
+	pool.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	dirty := newAccountSet(pool.signer)
+	dirty.addTx(tx)
+	<-pool.requestPromoteExecutables(dirty)
+	return nil
+}
+
+// IsPrivate returns whether the transaction with the given hash is currently
+// withheld from network propagation.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
 // AddRemotes enqueues a batch of transactions into the pool if they are valid. If the
 // senders are not among the locally tracked ones, full pricing constraints will apply.
 //
@@ -829,9 +1039,11 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
-		replaced, err := pool.add(tx, local)
+		_, err := pool.add(tx, local)
 		errs[i] = err
-		if err == nil && !replaced {
+		if err == nil {
+			// Replacements are promotion checked too, as a fee bump may be what
+			// lets the sender's queued dependents clear the fee floor.
 			dirty.addTx(tx)
 		}
 	}
@@ -1064,6 +1276,19 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
 		pool.demoteUnexecutables()
+
+		// Announce any private transactions that were released to the network
+		head := reset.newHead
+		if head == nil {
+			head = pool.chain.CurrentBlock().Header() // Special case during testing
+		}
+		for _, tx := range pool.releasePrivate(head.Number.Uint64()) {
+			addr, _ := types.Sender(pool.signer, tx)
+			if _, ok := events[addr]; !ok {
+				events[addr] = newTxSortedMap()
+			}
+			events[addr].Put(tx)
+		}
 	}
 	// Ensure pool.queue and pool.pending sizes stay within the configured limits.
 	pool.truncatePending()
@@ -1172,6 +1397,34 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.eip2718 = pool.chainconfig.IsBerlin(next)
+}
+
+// releasePrivate drops the private marker of all transactions withheld until the
+// given block number or earlier, returning the executable ones to be announced.
+// Markers of transactions no longer in the pool are cleaned up too.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) releasePrivate(number uint64) types.Transactions {
+	var released types.Transactions
+	for hash, expiry := range pool.private {
+		tx := pool.all.Get(hash)
+		if tx == nil {
+			delete(pool.private, hash)
+			continue
+		}
+		if expiry > number {
+			continue
+		}
+		delete(pool.private, hash)
+
+		addr, _ := types.Sender(pool.signer, tx) // already validated
+		if list := pool.pending[addr]; list != nil && list.txs.Get(tx.Nonce()) == tx {
+			released = append(released, tx)
+		}
+		log.Trace("Released private transaction", "hash", hash, "expiry", expiry)
+	}
+	return released
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1181,6 +1434,9 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 	// Track the promoted transactions to broadcast them at once
 	var promoted []*types.Transaction
 
+	// Remote transactions need to clear the current fee floor to be promoted
+	floor := pool.priceFloor()
+
 	// Iterate over all accounts and promote any executable transactions
 	for _, addr := range accounts {
 		list := pool.queue[addr]
@@ -1203,8 +1459,18 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		}
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
-		// Gather all executable transactions and promote them
+		// Gather all executable transactions, holding back any beyond the account
+		// quota or below the fee floor, and promote the rest
 		readies := list.Ready(pool.pendingNonces.get(addr))
+		if !pool.locals.contains(addr) {
+			if n, reason := pool.holdReady(addr, readies, floor); n < len(readies) {
+				for _, tx := range readies[n:] {
+					list.Add(tx, pool.config.PriceBump)
+				}
+				log.Trace("Holding back executable queued transactions", "addr", addr, "count", len(readies)-n, "reason", reason)
+				readies = readies[:n]
+			}
+		}
 		for _, tx := range readies {
 			hash := tx.Hash()
 			if pool.promoteTx(addr, hash, tx) {
@@ -1239,6 +1505,130 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 	return promoted
 }
 
+// priceFloor returns the minimum gas price remote transactions need to pay to be
+// promoted into the pending set. Below the configured fill threshold this is the
+// pool's own price limit, above it the floor rises linearly from the cheapest
+// pooled price up to FloorBump percent above it as the pending set fills up.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) priceFloor() *big.Int {
+	if pool.config.FloorThreshold == 0 {
+		return pool.gasPrice
+	}
+	pending := uint64(0)
+	for _, list := range pool.pending {
+		pending += uint64(list.Len())
+	}
+	threshold := pool.config.GlobalSlots * pool.config.FloorThreshold / 100
+	if pending < threshold {
+		return pool.gasPrice
+	}
+	cheapest := pool.priced.Cheapest()
+	if cheapest == nil {
+		return pool.gasPrice
+	}
+	fill, span := pending-threshold, pool.config.GlobalSlots-threshold
+	if span == 0 {
+		span = 1
+	}
+	if fill > span {
+		fill = span
+	}
+	floor := new(big.Int).Mul(cheapest, new(big.Int).SetUint64(100+pool.config.FloorBump*fill/span))
+	floor.Div(floor, big.NewInt(100))
+
+	if floor.Cmp(pool.gasPrice) < 0 {
+		return pool.gasPrice
+	}
+	return floor
+}
+
+// holdReady checks a nonce-contiguous batch of executable transactions from a
+// remote account against its pending quota and the fee floor. It returns the
+// number of leading transactions that may be promoted, along with the reason
+// the remainder needs to stay queued.
+//
+// The floor is enforced on the gas weighted average price of the batch prefix,
+// so a well paying transaction carries its cheaper dependents along: bumping the
+// fee of a single held nonce is enough to gap-fill all the queued ones after it.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) holdReady(addr common.Address, txs types.Transactions, floor *big.Int) (int, QueueReason) {
+	var (
+		limit  = len(txs)
+		reason = QueueReasonNone
+	)
+	if quota := pool.config.AccountPending; quota > 0 {
+		pending, room := uint64(0), 0
+		if list := pool.pending[addr]; list != nil {
+			pending = uint64(list.Len())
+		}
+		if pending < quota {
+			room = int(quota - pending)
+		}
+		if room < limit {
+			limit, reason = room, QueueReasonQuota
+		}
+	}
+	var (
+		paid    = new(big.Int)
+		gas     = new(big.Int)
+		minimum = new(big.Int)
+		allowed = 0
+	)
+	for i, tx := range txs[:limit] {
+		gas.Add(gas, new(big.Int).SetUint64(tx.Gas()))
+		paid.Add(paid, new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())))
+		if paid.Cmp(minimum.Mul(floor, gas)) >= 0 {
+			allowed = i + 1
+		}
+	}
+	if allowed < limit {
+		return allowed, QueueReasonPriceFloor
+	}
+	return limit, reason
+}
+
+// QueueReasons retrieves the reason each transaction of the non-executable queue
+// is being held back, grouped by account and keyed by nonce.
+func (pool *TxPool) QueueReasons() map[common.Address]map[uint64]QueueReason {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	floor := pool.priceFloor()
+	reasons := make(map[common.Address]map[uint64]QueueReason)
+	for addr, list := range pool.queue {
+		var (
+			txs   = list.Flatten()
+			next  = pool.pendingNonces.get(addr)
+			dump  = make(map[uint64]QueueReason, len(txs))
+			ready = 0
+		)
+		// Anything after the first missing nonce is waiting for the gap to fill
+		for _, tx := range txs {
+			if tx.Nonce() > next+uint64(ready) {
+				dump[tx.Nonce()] = QueueReasonNonceGap
+				continue
+			}
+			dump[tx.Nonce()] = QueueReasonNone
+			if tx.Nonce() >= next {
+				ready++
+			}
+		}
+		// Executable ones are held back by the quota or the floor unless local
+		if !pool.locals.contains(addr) && ready > 0 {
+			start := sort.Search(len(txs), func(i int) bool { return txs[i].Nonce() >= next })
+			if n, reason := pool.holdReady(addr, txs[start:start+ready], floor); n < ready {
+				for _, tx := range txs[start+n : start+ready] {
+					dump[tx.Nonce()] = reason
+				}
+			}
+		}
+		reasons[addr] = dump
+	}
+	return reasons
+}
+
 // truncatePending removes transactions from the pending queue if the pool is above the
 // pending limit. The algorithm tries to reduce transaction counts by an approximately
 // equal number for all for accounts with many pending transactions.
@@ -1148,6 +1148,125 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 	}
 }
 
+// Tests that remote accounts cannot promote more executable transactions than
+// their pending quota, holding the rest back in the queue, while local accounts
+// are exempt from the limit.
+func TestTransactionPendingQuota(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the limit enforcement with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.AccountPending = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Create a remote and a local account and fund them
+	remote, _ := crypto.GenerateKey()
+	local, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000))
+
+	// Import a batch of executable transactions from both, plus a gapped one
+	remotes, locals := types.Transactions{}, types.Transactions{}
+	for i := uint64(0); i < 5; i++ {
+		remotes = append(remotes, transaction(i, 100000, remote))
+		locals = append(locals, transaction(i, 100000, local))
+	}
+	remotes = append(remotes, transaction(6, 100000, remote))
+
+	pool.AddRemotesSync(remotes)
+	pool.AddLocals(locals)
+
+	pending, queued := pool.Stats()
+	if pending != 8 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 8)
+	}
+	if queued != 3 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 3)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Ensure the held back transactions are reported with the correct reasons
+	reasons := pool.QueueReasons()[crypto.PubkeyToAddress(remote.PublicKey)]
+	want := map[uint64]QueueReason{3: QueueReasonQuota, 4: QueueReasonQuota, 6: QueueReasonNonceGap}
+	if len(reasons) != len(want) {
+		t.Fatalf("queue reason count mismatch: have %d, want %d", len(reasons), len(want))
+	}
+	for nonce, reason := range want {
+		if reasons[nonce] != reason {
+			t.Errorf("nonce %d: queue reason mismatch: have %v, want %v", nonce, reasons[nonce], reason)
+		}
+	}
+}
+
+// Tests that once the pending pool fills past the floor threshold, remote
+// transactions need to outbid the cheapest pooled one to get promoted, and that
+// bumping the fee of a held transaction also promotes its cheaper dependents.
+func TestTransactionPriceFloor(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the fee floor with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 4
+	config.FloorThreshold = 50
+	config.FloorBump = 100
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Create two remote accounts and fund them
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Fill the pending pool past the threshold, raising the floor to 15 wei
+	pool.AddRemotesSync([]*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(10), keys[0]),
+		pricedTransaction(1, 100000, big.NewInt(10), keys[0]),
+		pricedTransaction(2, 100000, big.NewInt(10), keys[0]),
+	})
+	if floor := pool.priceFloor(); floor.Cmp(big.NewInt(15)) != 0 {
+		t.Fatalf("fee floor mismatch: have %v, want %v", floor, 15)
+	}
+	// Add transactions below the floor and ensure they are held back
+	pool.AddRemotesSync([]*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(10), keys[1]),
+		pricedTransaction(1, 100000, big.NewInt(10), keys[1]),
+	})
+	if pending, queued := pool.Stats(); pending != 3 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d pending %d queued, want %d pending %d queued", pending, queued, 3, 2)
+	}
+	for nonce, reason := range pool.QueueReasons()[crypto.PubkeyToAddress(keys[1].PublicKey)] {
+		if reason != QueueReasonPriceFloor {
+			t.Errorf("nonce %d: queue reason mismatch: have %v, want %v", nonce, reason, QueueReasonPriceFloor)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Bump the first held transaction enough to carry its dependent too
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(30), keys[1])); err != nil {
+		t.Fatalf("failed to replace held transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 5 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d pending %d queued, want %d pending %d queued", pending, queued, 5, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that setting the transaction pool gas price to a higher value correctly
 // discards everything cheaper than that and moves any gapped transactions back
 // from the pending pool to the queue.
@@ -1764,6 +1883,163 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that private transactions keep their marker across restarts through the
+// journal, and are released to the network once their withholding period ends.
+func TestTransactionPrivate(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject a private and a plain local transaction
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+	config.PrivateBlocks = 5
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	private := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddPrivate(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	plain := pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.AddLocal(plain); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddPrivate(plain); err != ErrAlreadyKnown {
+		t.Fatalf("known transaction privatization error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if !pool.IsPrivate(private.Hash()) || pool.IsPrivate(plain.Hash()) {
+		t.Fatalf("private markers mismatch: have %v/%v, want true/false", pool.IsPrivate(private.Hash()), pool.IsPrivate(plain.Hash()))
+	}
+	// Terminate the old pool, create a new one and ensure the marker survived
+	pool.Stop()
+
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if !pool.IsPrivate(private.Hash()) || pool.IsPrivate(plain.Hash()) {
+		t.Fatalf("private markers mismatch after restart: have %v/%v, want true/false", pool.IsPrivate(private.Hash()), pool.IsPrivate(plain.Hash()))
+	}
+	// Advance the chain before the expiry and ensure the transaction stays private
+	events := make(chan NewTxsEvent, 32)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(4), GasLimit: 1000000})
+	if !pool.IsPrivate(private.Hash()) {
+		t.Fatalf("private transaction released early")
+	}
+	// Reach the expiry and ensure the transaction is released and announced
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(5), GasLimit: 1000000})
+	if pool.IsPrivate(private.Hash()) {
+		t.Fatalf("private transaction not released")
+	}
+	if err := validateEvents(events, 1); err != nil {
+		t.Fatalf("release event firing failed: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that remote transactions survive a restart through the pool snapshot,
+// bounded by the snapshot limit and revalidated against the new state.
+func TestTransactionSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	snapshot := file.Name()
+	defer os.Remove(snapshot)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(snapshot)
+
+	// Create the original pool to inject transactions into the snapshot
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Snapshot = snapshot
+	config.SnapshotLimit = 4
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	pool.AddRemotesSync([]*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), keys[0]),
+		pricedTransaction(1, 100000, big.NewInt(1), keys[0]),
+		pricedTransaction(3, 100000, big.NewInt(1), keys[0]),
+		pricedTransaction(0, 100000, big.NewInt(5), keys[1]),
+	})
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), keys[2])); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddPrivate(pricedTransaction(1, 100000, big.NewInt(1), keys[2])); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	// Exports include locals but never private transactions, executables first
+	txs := pool.Export()
+	if len(txs) != 4 {
+		t.Fatalf("exported transactions mismatched: have %d, want %d", len(txs), 4)
+	}
+	for _, tx := range txs {
+		if pool.IsPrivate(tx.Hash()) {
+			t.Fatalf("private transaction %x exported", tx.Hash())
+		}
+		if tx.Nonce() == 3 {
+			t.Fatalf("queued transaction exported over executable ones")
+		}
+	}
+	// Terminate the old pool, bump a remote nonce, create a new pool and ensure
+	// only the valid remote transactions were restored
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(keys[1].PublicKey), 1)
+
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
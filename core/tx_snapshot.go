@@ -0,0 +1,114 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io"
+	"os"
+
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/log"
+	"github.com/luck/go-luck/rlp"
+)
+
+// txSnapshot is a point in time dump of the remote transactions of the pool,
+// allowing a restarted node to resume with a populated pool instead of waiting
+// for its peers to gossip everything again.
+type txSnapshot struct {
+	path string // Filesystem path to store the transactions at
+}
+
+// newTxSnapshot creates a new transaction pool snapshot at the given path.
+func newTxSnapshot(path string) *txSnapshot {
+	return &txSnapshot{
+		path: path,
+	}
+}
+
+// load parses a transaction pool snapshot from disk, injecting its contents in
+// small-ish batches into the specified pool, which revalidates all of them.
+func (snapshot *txSnapshot) load(add func([]*types.Transaction) []error) error {
+	// Skip the parsing if the snapshot file doesn't exist at all
+	if _, err := os.Stat(snapshot.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(snapshot.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream = rlp.NewStream(input, 0)
+		batch  types.Transactions
+
+		total, dropped int
+		failure        error
+	)
+	loadBatch := func(txs types.Transactions) {
+		for _, err := range add(txs) {
+			if err != nil {
+				log.Debug("Failed to add snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for {
+		// Parse the next transaction and terminate on error
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			if batch.Len() > 0 {
+				loadBatch(batch)
+			}
+			break
+		}
+		total++
+
+		if batch = append(batch, tx); batch.Len() > 1024 {
+			loadBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	log.Info("Loaded transaction pool snapshot", "transactions", total, "dropped", dropped)
+
+	return failure
+}
+
+// save atomically replaces the snapshot on disk with the given transactions.
+func (snapshot *txSnapshot) save(txs types.Transactions) error {
+	replacement, err := os.OpenFile(snapshot.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if err = rlp.Encode(replacement, tx); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	if err = replacement.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(snapshot.path+".new", snapshot.path); err != nil {
+		return err
+	}
+	log.Info("Saved transaction pool snapshot", "transactions", len(txs))
+	return nil
+}
@@ -83,11 +83,11 @@ type Header struct {
 	Extra       []byte         `json:"extraData"        gencodec:"required"`
 	
 	//Range       uint64         `json:"range"            gencodec:"required"`
-	Lucky       *big.Int       `json:"lucky"            gencodec:"required"`
-	Basis       *big.Int       `json:"basis"            gencodec:"required"`
+	Lucky       *big.Int       `json:"lucky"`
+	Basis       *big.Int       `json:"basis"`
 	FirstNonce   BlockNonce    `json:"firstNonce"       gencodec:"required"`
-	DifficultyAlpha *big.Int   `json:"difficultyAlpha"  gencodec:"required"`
-	DifficultyBeta  *big.Int   `json:"difficultyBeta"   gencodec:"required"`
+	DifficultyAlpha *big.Int   `json:"difficultyAlpha"`
+	DifficultyBeta  *big.Int   `json:"difficultyBeta"`
 	Difficulty  *big.Int       `json:"difficulty"       gencodec:"required"`
 	SecondNonce BlockNonce     `json:"secondNonce"      gencodec:"required"`
 
@@ -97,13 +97,17 @@ type Header struct {
 
 // field type overrides for gencodec
 type headerMarshaling struct {
-	Difficulty *hexutil.Big
-	Number     *hexutil.Big
-	GasLimit   hexutil.Uint64
-	GasUsed    hexutil.Uint64
-	Time       hexutil.Uint64
-	Extra      hexutil.Bytes
-	Hash       common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
+	Lucky           *hexutil.Big
+	Basis           *hexutil.Big
+	DifficultyAlpha *hexutil.Big
+	DifficultyBeta  *hexutil.Big
+	Difficulty      *hexutil.Big
+	Number          *hexutil.Big
+	GasLimit        hexutil.Uint64
+	GasUsed         hexutil.Uint64
+	Time            hexutil.Uint64
+	Extra           hexutil.Bytes
+	Hash            common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
 }
 
 // Hash returns the block hash of the header, which is simply the keccak256 hash of its
@@ -146,6 +150,16 @@ func rlpHash(x interface{}) (h common.Hash) {
 	return h
 }
 
+// prefixedRlpHash writes the prefix into the hasher before rlp-encoding x.
+// It's used for typed transactions.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewLegacyKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
 // Body is a simple (mutable, non-safe) data container for storing and moving
 // a block's data contents (transactions and uncles) tolucker.
 type Body struct {
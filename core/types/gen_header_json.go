@@ -16,22 +16,28 @@ var _ = (*headerMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash  common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    common.Address `json:"miner"            gencodec:"required"`
-		Root        common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big   `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big   `json:"number"           gencodec:"required"`
-		GasLimit    hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
-		Extra       hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   common.Hash    `json:"mixHash"`
-		Nonce       BlockNonce     `json:"nonce"`
-		Hash        common.Hash    `json:"hash"`
+		ParentHash      common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash       common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase        common.Address `json:"miner"            gencodec:"required"`
+		Root            common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash          common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash     common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom           Bloom          `json:"logsBloom"        gencodec:"required"`
+		Number          *hexutil.Big   `json:"number"           gencodec:"required"`
+		GasLimit        hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed         hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time            hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
+		Extra           hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		Lucky           *hexutil.Big   `json:"lucky"`
+		Basis           *hexutil.Big   `json:"basis"`
+		FirstNonce      BlockNonce     `json:"firstNonce"       gencodec:"required"`
+		DifficultyAlpha *hexutil.Big   `json:"difficultyAlpha"`
+		DifficultyBeta  *hexutil.Big   `json:"difficultyBeta"`
+		Difficulty      *hexutil.Big   `json:"difficulty"       gencodec:"required"`
+		SecondNonce     BlockNonce     `json:"secondNonce"      gencodec:"required"`
+		MixDigest       common.Hash    `json:"mixHash"`
+		Nonce           BlockNonce     `json:"nonce"`
+		Hash            common.Hash    `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -41,12 +47,18 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.TxHash = h.TxHash
 	enc.ReceiptHash = h.ReceiptHash
 	enc.Bloom = h.Bloom
-	enc.Difficulty = (*hexutil.Big)(h.Difficulty)
 	enc.Number = (*hexutil.Big)(h.Number)
 	enc.GasLimit = hexutil.Uint64(h.GasLimit)
 	enc.GasUsed = hexutil.Uint64(h.GasUsed)
 	enc.Time = hexutil.Uint64(h.Time)
 	enc.Extra = h.Extra
+	enc.Lucky = (*hexutil.Big)(h.Lucky)
+	enc.Basis = (*hexutil.Big)(h.Basis)
+	enc.FirstNonce = h.FirstNonce
+	enc.DifficultyAlpha = (*hexutil.Big)(h.DifficultyAlpha)
+	enc.DifficultyBeta = (*hexutil.Big)(h.DifficultyBeta)
+	enc.Difficulty = (*hexutil.Big)(h.Difficulty)
+	enc.SecondNonce = h.SecondNonce
 	enc.MixDigest = h.MixDigest
 	enc.Nonce = h.Nonce
 	enc.Hash = h.Hash()
@@ -56,21 +68,27 @@ func (h Header) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash  *common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    *common.Address `json:"miner"            gencodec:"required"`
-		Root        *common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      *common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       *Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit    *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
-		Extra       *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   *common.Hash    `json:"mixHash"`
-		Nonce       *BlockNonce     `json:"nonce"`
+		ParentHash      *common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash       *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase        *common.Address `json:"miner"            gencodec:"required"`
+		Root            *common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash          *common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash     *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom           *Bloom          `json:"logsBloom"        gencodec:"required"`
+		Number          *hexutil.Big    `json:"number"           gencodec:"required"`
+		GasLimit        *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed         *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time            *hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
+		Extra           *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		Lucky           *hexutil.Big    `json:"lucky"`
+		Basis           *hexutil.Big    `json:"basis"`
+		FirstNonce      *BlockNonce     `json:"firstNonce"       gencodec:"required"`
+		DifficultyAlpha *hexutil.Big    `json:"difficultyAlpha"`
+		DifficultyBeta  *hexutil.Big    `json:"difficultyBeta"`
+		Difficulty      *hexutil.Big    `json:"difficulty"       gencodec:"required"`
+		SecondNonce     *BlockNonce     `json:"secondNonce"      gencodec:"required"`
+		MixDigest       *common.Hash    `json:"mixHash"`
+		Nonce           *BlockNonce     `json:"nonce"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -104,10 +122,6 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'logsBloom' for Header")
 	}
 	h.Bloom = *dec.Bloom
-	if dec.Difficulty == nil {
-		return errors.New("missing required field 'difficulty' for Header")
-	}
-	h.Difficulty = (*big.Int)(dec.Difficulty)
 	if dec.Number == nil {
 		return errors.New("missing required field 'number' for Header")
 	}
@@ -128,6 +142,30 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'extraData' for Header")
 	}
 	h.Extra = *dec.Extra
+	if dec.Lucky != nil {
+		h.Lucky = (*big.Int)(dec.Lucky)
+	}
+	if dec.Basis != nil {
+		h.Basis = (*big.Int)(dec.Basis)
+	}
+	if dec.FirstNonce == nil {
+		return errors.New("missing required field 'firstNonce' for Header")
+	}
+	h.FirstNonce = *dec.FirstNonce
+	if dec.DifficultyAlpha != nil {
+		h.DifficultyAlpha = (*big.Int)(dec.DifficultyAlpha)
+	}
+	if dec.DifficultyBeta != nil {
+		h.DifficultyBeta = (*big.Int)(dec.DifficultyBeta)
+	}
+	if dec.Difficulty == nil {
+		return errors.New("missing required field 'difficulty' for Header")
+	}
+	h.Difficulty = (*big.Int)(dec.Difficulty)
+	if dec.SecondNonce == nil {
+		return errors.New("missing required field 'secondNonce' for Header")
+	}
+	h.SecondNonce = *dec.SecondNonce
 	if dec.MixDigest != nil {
 		h.MixDigest = *dec.MixDigest
 	}
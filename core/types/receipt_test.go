@@ -281,3 +281,47 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+// TestTypedReceiptEncoding tests that typed receipts are prefixed by their type
+// in the consensus encoding and round trip through RLP.
+func TestTypedReceiptEncoding(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{{
+			Address: common.BytesToAddress([]byte{0x11}),
+			Topics:  []common.Hash{common.HexToHash("dead")},
+			Data:    []byte{0x01, 0x00, 0xff},
+		}},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	// The trie encoding is the type byte followed by the RLP list
+	blob := Receipts{receipt}.GetRlp(0)
+	if blob[0] != AccessListTxType {
+		t.Fatalf("type prefix mismatch: have %d, want %d", blob[0], AccessListTxType)
+	}
+	payload, err := rlp.EncodeToBytes(&receiptRLP{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blob[1:], payload) {
+		t.Fatalf("payload mismatch: have %x, want %x", blob[1:], payload)
+	}
+	// The RLP encoding wraps the same bytes into a string
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Receipt)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("decoding failed: %v", err)
+	}
+	if dec.Type != receipt.Type || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed {
+		t.Errorf("decoded receipt mismatch: have %+v, want %+v", dec, receipt)
+	}
+	if len(dec.Logs) != 1 || !bytes.Equal(dec.Logs[0].Data, receipt.Logs[0].Data) {
+		t.Errorf("decoded logs mismatch: have %v, want %v", dec.Logs, receipt.Logs)
+	}
+}
@@ -219,3 +219,87 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+// TestAccessListTransaction tests signing, encoding and decoding of access list
+// transactions.
+func TestAccessListTransaction(t *testing.T) {
+	key, addr := defaultTestKey()
+	signer := NewEIP2930Signer(big.NewInt(1))
+
+	to := common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	tx, err := SignNewTx(key, signer, &AccessListTx{
+		ChainID:  big.NewInt(1),
+		Nonce:    3,
+		To:       &to,
+		Value:    big.NewInt(10),
+		Gas:      25000,
+		GasPrice: big.NewInt(1),
+		Data:     common.FromHex("5544"),
+		AccessList: AccessList{
+			{Address: to, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx.Type() != AccessListTxType {
+		t.Fatalf("type mismatch: have %d, want %d", tx.Type(), AccessListTxType)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	if _, err := Sender(NewEIP155Signer(big.NewInt(1)), tx); err != ErrTxTypeNotSupported {
+		t.Errorf("legacy signer error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	if _, err := Sender(NewEIP2930Signer(big.NewInt(2)), tx); err != ErrInvalidChainId {
+		t.Errorf("foreign chain signer error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+	// The binary encoding is the type byte followed by the RLP payload
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("binary encoding failed: %v", err)
+	}
+	if blob[0] != AccessListTxType {
+		t.Fatalf("binary encoding type prefix mismatch: have %d, want %d", blob[0], AccessListTxType)
+	}
+	dec := new(Transaction)
+	if err := dec.UnmarshalBinary(blob); err != nil {
+		t.Fatalf("binary decoding failed: %v", err)
+	}
+	assertEqual(t, dec, tx)
+
+	// Within RLP lists the typed encoding is wrapped into a string
+	enc, err := rlp.EncodeToBytes(Transactions{tx})
+	if err != nil {
+		t.Fatalf("rlp encoding failed: %v", err)
+	}
+	var txs Transactions
+	if err := rlp.DecodeBytes(enc, &txs); err != nil {
+		t.Fatalf("rlp decoding failed: %v", err)
+	}
+	assertEqual(t, txs[0], tx)
+
+	// JSON must round trip too, keeping the access list
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json encoding failed: %v", err)
+	}
+	dec = new(Transaction)
+	if err := json.Unmarshal(data, dec); err != nil {
+		t.Fatalf("json decoding failed: %v", err)
+	}
+	assertEqual(t, dec, tx)
+	if len(dec.AccessList()) != 1 || dec.AccessList().StorageKeys() != 2 {
+		t.Errorf("access list mismatch: have %v", dec.AccessList())
+	}
+}
+
+func assertEqual(t *testing.T, have, want *Transaction) {
+	t.Helper()
+	if have.Hash() != want.Hash() {
+		t.Fatalf("transaction hash mismatch: have %x, want %x", have.Hash(), want.Hash())
+	}
+	if have.Type() != want.Type() {
+		t.Fatalf("transaction type mismatch: have %d, want %d", have.Type(), want.Type())
+	}
+}
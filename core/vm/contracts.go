@@ -17,17 +17,22 @@
 package vm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"math/big"
+	"runtime"
+	"sort"
 
 	"github.com/luck/go-luck/common"
 	"github.com/luck/go-luck/common/math"
+	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/crypto"
 	"github.com/luck/go-luck/crypto/blake2b"
 	"github.com/luck/go-luck/crypto/bn256"
 	"github.com/luck/go-luck/params"
+	"github.com/luck/go-luck/rlp"
 
 	//lint:ignore SA1019 Needed for precompile
 	"golang.org/x/crypto/ripemd160"
@@ -77,6 +82,62 @@ var PrecompiledContractsIstanbul = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{9}): &blake2F{},
 }
 
+// tppowSealAddress is the address of the Tppow seal verification precompile.
+var tppowSealAddress = common.BytesToAddress([]byte{11})
+
+// PrecompiledContractsTppow contains the default set of pre-compiled Luck
+// contracts used since the Tppow precompiles fork. The seal verification
+// contract listed here is unbound, the EVM replaces it with one verifying seals
+// against the consensus parameters of the running chain.
+var PrecompiledContractsTppow = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1}):  &ecrecover{},
+	common.BytesToAddress([]byte{2}):  &sha256hash{},
+	common.BytesToAddress([]byte{3}):  &ripemd160hash{},
+	common.BytesToAddress([]byte{4}):  &dataCopy{},
+	common.BytesToAddress([]byte{5}):  &bigModExp{},
+	common.BytesToAddress([]byte{6}):  &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}):  &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}):  &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}):  &blake2F{},
+	common.BytesToAddress([]byte{10}): &argon2id{},
+	tppowSealAddress:                  &tppowSeal{},
+}
+
+var (
+	PrecompiledAddressesTppow     = precompiledAddresses(PrecompiledContractsTppow)
+	PrecompiledAddressesIstanbul  = precompiledAddresses(PrecompiledContractsIstanbul)
+	PrecompiledAddressesByzantium = precompiledAddresses(PrecompiledContractsByzantium)
+	PrecompiledAddressesHomestead = precompiledAddresses(PrecompiledContractsHomestead)
+)
+
+// precompiledAddresses returns the addresses of a precompile set in ascending
+// order.
+func precompiledAddresses(contracts map[common.Address]PrecompiledContract) []common.Address {
+	addrs := make([]common.Address, 0, len(contracts))
+	for addr := range contracts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
+// ActivePrecompiles returns the addresses of the precompiles enabled with the
+// current configuration.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	switch {
+	case rules.IsTppowPrecompiles:
+		return PrecompiledAddressesTppow
+	case rules.IsIstanbul:
+		return PrecompiledAddressesIstanbul
+	case rules.IsByzantium:
+		return PrecompiledAddressesByzantium
+	default:
+		return PrecompiledAddressesHomestead
+	}
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -502,3 +563,101 @@ func (c *blake2F) Run(input []byte) ([]byte, error) {
 	}
 	return output, nil
 }
+
+var (
+	errArgon2Memory   = errors.New("invalid argon2 memory size")
+	errArgon2Input    = errors.New("invalid argon2 input length")
+	errTppowSealInput = errors.New("invalid tppow header encoding")
+	errTppowSealChain = errors.New("tppow seal verification unavailable")
+)
+
+// maxArgon2Hashers is the maximum number of argon2id precompile hashes allowed
+// to run concurrently, each needing an Argon2 memory area.
+const maxArgon2Hashers = 4
+
+// argon2Hashers bounds the concurrently running argon2id precompile hashes, so
+// that parallel calls (e.g. RPC calls and block processing) don't allocate an
+// Argon2 memory area on every available core at once.
+var argon2Hashers = make(chan struct{}, argon2HasherCount())
+
+// argon2HasherCount returns the number of argon2id hashes allowed to run
+// concurrently, the smaller of the available cores and maxArgon2Hashers.
+func argon2HasherCount() int {
+	if n := runtime.GOMAXPROCS(0); n < maxArgon2Hashers {
+		return n
+	}
+	return maxArgon2Hashers
+}
+
+// argon2id implements the Argon2id hash of the Tppow seal stages as a native
+// contract. The input is encoded as
+//
+//	memory (32 bytes) || salt length (32 bytes) || salt || data
+//
+// with the memory size given in KiB. The time cost, parallelism and output size
+// are fixed to those of the consensus hashes, the output is the 32 byte hash.
+type argon2id struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *argon2id) RequiredGas(input []byte) uint64 {
+	memory := new(big.Int).SetBytes(getData(input, 0, 32))
+	if !memory.IsUint64() || memory.Uint64() > params.Argon2MaxMemory {
+		return math.MaxUint64
+	}
+	return params.Argon2BaseGas + memory.Uint64()*params.Argon2PerKiBGas + uint64(len(input)+31)/32*params.Argon2PerWordGas
+}
+
+func (c *argon2id) Run(input []byte) ([]byte, error) {
+	var (
+		memory  = new(big.Int).SetBytes(getData(input, 0, 32))
+		saltLen = new(big.Int).SetBytes(getData(input, 32, 32))
+	)
+	if !memory.IsUint64() || memory.Uint64() == 0 || memory.Uint64() > params.Argon2MaxMemory {
+		return nil, errArgon2Memory
+	}
+	if len(input) < 64 || !saltLen.IsUint64() || saltLen.Uint64() > uint64(len(input)-64) {
+		return nil, errArgon2Input
+	}
+	var (
+		salt = input[64 : 64+saltLen.Uint64()]
+		data = input[64+saltLen.Uint64():]
+	)
+	argon2Hashers <- struct{}{}
+	defer func() { <-argon2Hashers }()
+
+	return crypto.Argon2HashWithMemory(data, salt, uint32(memory.Uint64())), nil
+}
+
+// tppowSeal implements the verification of the proof-of-work seal of an RLP
+// encoded Tppow header as a native contract. The output is the hash of the
+// header if its seal is valid and empty otherwise.
+type tppowSeal struct {
+	config *params.TppowConfig // Consensus parameters pricing the seal, nil for the defaults
+	verify VerifySealFunc      // Seal verifier of the consensus engine, nil if unavailable
+}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract,
+// covering the three Argon2id hashes of the seal at the height of the header.
+func (c *tppowSeal) RequiredGas(input []byte) uint64 {
+	gas := params.TppowSealGas + uint64(len(input)+31)/32*params.Argon2PerWordGas
+
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(input, header); err != nil {
+		return gas
+	}
+	return gas + 3*uint64(c.config.WithDefaults().Argon2Memory(header.Number))*params.Argon2PerKiBGas
+}
+
+func (c *tppowSeal) Run(input []byte) ([]byte, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(input, header); err != nil {
+		return nil, errTppowSealInput
+	}
+	if c.verify == nil {
+		return nil, errTppowSealChain
+	}
+	if err := c.verify(header); err != nil {
+		return nil, nil
+	}
+	return header.Hash().Bytes(), nil
+}
@@ -21,9 +21,14 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus/tppow"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -661,3 +666,326 @@ func TestPrecompiledEcrecover(t *testing.T) {
 	}
 
 }
+
+// argon2Tests are the test and benchmark data for the argon2id precompiled
+// contract, the last one being the first stage hash of the sealed header below.
+var argon2Tests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "96571af93b78a257664f278a51d9e1d229062c25e3c0b6d1b5b9b337be012911",
+		name:     "empty",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000040" +
+			"000000000000000000000000000000000000000000000000000000000000000a" +
+			"7470706f772073616c74" +
+			"6c75636b",
+		expected: "68d015e87e306fb467f2502ec4e9cb152c3e1213b458b3a36dadd196f5721fb4",
+		name:     "64KiB",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000010000" +
+			"000000000000000000000000000000000000000000000000000000000000000a" +
+			"23da88062c3c63670c87" +
+			"f841a09a8aacea47052ceb346c3a2ad43c6a65639ebef2d00b23da88062c3c63670c8799000000000071562b71999873db5b286df957af199ec94617f7845f16c1e507",
+		expected: "6791f2cd8f6be3cd84826bf239c74086a92f92d40a34872f7f916a66af5944d0",
+		name:     "tppow_luck_stage",
+	},
+}
+
+// argon2MalformedInputTests are the failure test data for the argon2id
+// precompiled contract.
+var argon2MalformedInputTests = []precompiledFailureTest{
+	{
+		input:         "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errArgon2Memory,
+		name:          "zero_memory",
+	}, {
+		input:         "00000000000000000000000000000000000000000000000000000000000100010000000000000000000000000000000000000000000000000000000000000000",
+		expectedError: errArgon2Memory,
+		name:          "excess_memory",
+	}, {
+		input:         "0000000000000000000000000000000000000000000000000000000000000040",
+		expectedError: errArgon2Input,
+		name:          "missing_salt_length",
+	}, {
+		input:         "0000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000b7470706f772073616c74",
+		expectedError: errArgon2Input,
+		name:          "short_salt",
+	},
+}
+
+// testTppowPrecompiled, testTppowPrecompiledOOG, testTppowPrecompiledFailure
+// and benchmarkTppowPrecompiled are the counterparts of the above helpers for
+// the contracts only available from the Tppow precompile fork.
+func testTppowPrecompiled(addr string, test precompiledTest, t *testing.T) {
+	p := PrecompiledContractsTppow[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(AccountRef(common.HexToAddress("1337")),
+		nil, new(big.Int), p.RequiredGas(in))
+	t.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas), func(t *testing.T) {
+		if res, err := RunPrecompiledContract(p, in, contract); err != nil {
+			t.Error(err)
+		} else if common.Bytes2Hex(res) != test.expected {
+			t.Errorf("Expected %v, got %v", test.expected, common.Bytes2Hex(res))
+		}
+		// Verify that the precompile did not touch the input buffer
+		exp := common.Hex2Bytes(test.input)
+		if !bytes.Equal(in, exp) {
+			t.Errorf("Precompiled %v modified input data", addr)
+		}
+	})
+}
+
+func testTppowPrecompiledOOG(addr string, test precompiledTest, t *testing.T) {
+	p := PrecompiledContractsTppow[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(AccountRef(common.HexToAddress("1337")),
+		nil, new(big.Int), p.RequiredGas(in)-1)
+	t.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas), func(t *testing.T) {
+		_, err := RunPrecompiledContract(p, in, contract)
+		if err.Error() != "out of gas" {
+			t.Errorf("Expected error [out of gas], got [%v]", err)
+		}
+		// Verify that the precompile did not touch the input buffer
+		exp := common.Hex2Bytes(test.input)
+		if !bytes.Equal(in, exp) {
+			t.Errorf("Precompiled %v modified input data", addr)
+		}
+	})
+}
+
+func testTppowPrecompiledFailure(addr string, test precompiledFailureTest, t *testing.T) {
+	p := PrecompiledContractsTppow[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(AccountRef(common.HexToAddress("31337")),
+		nil, new(big.Int), p.RequiredGas(in))
+
+	t.Run(test.name, func(t *testing.T) {
+		_, err := RunPrecompiledContract(p, in, contract)
+		if !reflect.DeepEqual(err, test.expectedError) {
+			t.Errorf("Expected error [%v], got [%v]", test.expectedError, err)
+		}
+		// Verify that the precompile did not touch the input buffer
+		exp := common.Hex2Bytes(test.input)
+		if !bytes.Equal(in, exp) {
+			t.Errorf("Precompiled %v modified input data", addr)
+		}
+	})
+}
+
+func benchmarkTppowPrecompiled(addr string, test precompiledTest, bench *testing.B) {
+	if test.noBenchmark {
+		return
+	}
+	p := PrecompiledContractsTppow[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	reqGas := p.RequiredGas(in)
+	contract := NewContract(AccountRef(common.HexToAddress("1337")),
+		nil, new(big.Int), reqGas)
+
+	var (
+		res  []byte
+		err  error
+		data = make([]byte, len(in))
+	)
+
+	bench.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas), func(bench *testing.B) {
+		bench.ResetTimer()
+		for i := 0; i < bench.N; i++ {
+			contract.Gas = reqGas
+			copy(data, in)
+			res, err = RunPrecompiledContract(p, data, contract)
+		}
+		bench.StopTimer()
+		//Check if it is correct
+		if err != nil {
+			bench.Error(err)
+			return
+		}
+		if common.Bytes2Hex(res) != test.expected {
+			bench.Error(fmt.Sprintf("Expected %v, got %v", test.expected, common.Bytes2Hex(res)))
+			return
+		}
+	})
+}
+
+func TestPrecompiledArgon2id(t *testing.T) {
+	for _, test := range argon2Tests {
+		testTppowPrecompiled("0a", test, t)
+	}
+}
+
+func TestPrecompiledArgon2idOOG(t *testing.T) {
+	for _, test := range argon2Tests {
+		testTppowPrecompiledOOG("0a", test, t)
+	}
+}
+
+func TestPrecompiledArgon2idMalformedInput(t *testing.T) {
+	for _, test := range argon2MalformedInputTests {
+		testTppowPrecompiledFailure("0a", test, t)
+	}
+}
+
+// Tests that argon2id hashes wait for a free hasher slot instead of allocating
+// their memory areas unbounded.
+func TestPrecompiledArgon2idConcurrency(t *testing.T) {
+	for i := 0; i < cap(argon2Hashers); i++ {
+		argon2Hashers <- struct{}{}
+	}
+	done := make(chan struct{})
+	go func() {
+		PrecompiledContractsTppow[common.BytesToAddress([]byte{10})].Run(common.Hex2Bytes(argon2Tests[0].input))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("hash ran with all hasher slots taken")
+	case <-time.After(100 * time.Millisecond):
+	}
+	<-argon2Hashers
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("hash didn't run after a hasher slot was freed")
+	}
+	for i := 1; i < cap(argon2Hashers); i++ {
+		<-argon2Hashers
+	}
+}
+
+func BenchmarkPrecompiledArgon2id(bench *testing.B) {
+	for _, test := range argon2Tests {
+		benchmarkTppowPrecompiled("0a", test, bench)
+	}
+}
+
+// tppowSealChainTests are headers checked against the main network parameters:
+// the genesis headers of the main and test networks (0x9a8aacea...c3670c87 and
+// 0xefb16b02...fa1bbe9b), which carry no proof-of-work and must be reported as
+// invalid, and sealed headers at block 1 and at the difficulty adjustment block
+// (39200), where the difficulty is derived from the basis instead of the luck.
+var tppowSealChainTests = []precompiledTest{
+	{
+		input: "f90232a00000000000000000000000000000000000000000000000000000000000000000a01dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142" +
+			"fd40d493479900000000000000000000000000000000000000000000000000a035f8247eadcc31f01ae79dc887ce849eaea6915d79b0a73704af3e92665ba7ff" +
+			"a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363" +
+			"b421b901000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000808402faf08080845f16c1d1a0532dd24cac0f2271047aafec9dda071b7bdaaa79407d0117fb63e7b8609ad3f18080880000000000000000808082" +
+			"03e8880000000000000000a0000000000000000000000000000000000000000000000000000000000000000088000000000000004f",
+		expected: "",
+		name:     "mainnet_genesis",
+	}, {
+		input: "f9022ca00000000000000000000000000000000000000000000000000000000000000000a01dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142" +
+			"fd40d493479900000000000000000000000000000000000000000000000000a096c787d66299cb560f6307a3e36e08f1e3df2beb02ca02edc24e105d15262e55" +
+			"a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363" +
+			"b421b901000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"000000000080841dcd65008080a011bbe8db4e347b4e8c937c1c8370e4b5ed33adb3db69cbdb7a38e1e50b1b82fa808088000000000000000080806488000000" +
+			"0000000000a00000000000000000000000000000000000000000000000000000000000000000880000000000000042",
+		expected: "",
+		name:     "testnet_genesis",
+	}, {
+		input:    tppowSealHeader,
+		expected: "92ebdff6f40f1df75ccfe41d7c0e54c700a3835d56b69a1962a94510b30bbe6b",
+		name:     "mainnet_block_1",
+	}, {
+		input: "f9026ba03b7751512f4a81b0b33ff285da880b67f5f14f5c6b83c2a58594e4ddf52f3240a01dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142" +
+			"fd40d4934799000000000071562b71999873db5b286df957af199ec94617f7a035f8247eadcc31f01ae79dc887ce849eaea6915d79b0a73704af3e92665ba7ff" +
+			"a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363" +
+			"b421b901000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000829920837a120080845f2107008084014c007a9a0100000000000000000000000000000000000000000000000000880000000000000007a0ffffff" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffff9a014250959330bc30849e1a777b6c03d25da9cc771f8f5ad8fd9f82465e8800000000" +
+			"00000003a00000000000000000000000000000000000000000000000000000000000000000880000000000000000",
+		expected: "c03275f57d800bd7df2602b71573c6891e7210f0635bf2d469480642ccb0d67e",
+		name:     "mainnet_block_39200",
+	},
+}
+
+// tppowSealHeader is a block 1 header on top of the main network genesis, sealed
+// under the main network Tppow parameters.
+const tppowSealHeader = "f9026ca09a8aacea47052ceb346c3a2ad43c6a65639ebef2d00b23da88062c3c63670c87a01dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142" +
+	"fd40d4934799000000000071562b71999873db5b286df957af199ec94617f7a035f8247eadcc31f01ae79dc887ce849eaea6915d79b0a73704af3e92665ba7ff" +
+	"a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363" +
+	"b421b901000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"0000000000018402faf08080845f16c1e58084094dce119a0100000000000000000000000000000000000000000000000000880000000000000007a0ffffffff" +
+	"ffffffffffffffffffffffffffffffffffffffffffffffffffffffff9a14bfebbe715e6411a9d7a985434fb2d68269fe4af4ca3d1ad9dc84094dce1188000000" +
+	"0000000003a00000000000000000000000000000000000000000000000000000000000000000880000000000000000"
+
+// Tests that the seal verification precompile accepts valid seals, rejects
+// invalid ones and charges for the Argon2id hashes at the header's height.
+func TestPrecompiledTppowSeal(t *testing.T) {
+	var (
+		engine = tppow.NewFaker()
+		valid  = common.Hex2Bytes(tppowSealHeader)
+		bad    = common.Hex2Bytes(strings.Replace(tppowSealHeader, "84094dce119a01", "84094dce129a01", 1))
+		p      = &tppowSeal{verify: engine.VerifyHeaderSeal}
+	)
+	if have, want := p.RequiredGas(valid), params.TppowSealGas+uint64(len(valid)+31)/32*params.Argon2PerWordGas+3*64*1024*params.Argon2PerKiBGas; have != want {
+		t.Errorf("gas mismatch: have %d, want %d", have, want)
+	}
+	if res, err := p.Run(valid); err != nil {
+		t.Errorf("valid seal failed: %v", err)
+	} else if want := "92ebdff6f40f1df75ccfe41d7c0e54c700a3835d56b69a1962a94510b30bbe6b"; common.Bytes2Hex(res) != want {
+		t.Errorf("valid seal result mismatch: have %x, want %s", res, want)
+	}
+	if res, err := p.Run(bad); err != nil || len(res) != 0 {
+		t.Errorf("invalid seal: have %x, %v, want empty result", res, err)
+	}
+	if _, err := p.Run(valid[:len(valid)-1]); err != errTppowSealInput {
+		t.Errorf("truncated header: have %v, want %v", err, errTppowSealInput)
+	}
+	if _, err := PrecompiledContractsTppow[tppowSealAddress].Run(valid); err != errTppowSealChain {
+		t.Errorf("unbound verifier: have %v, want %v", err, errTppowSealChain)
+	}
+	for _, test := range tppowSealChainTests {
+		in := common.Hex2Bytes(test.input)
+		if have, want := p.RequiredGas(in), params.TppowSealGas+uint64(len(in)+31)/32*params.Argon2PerWordGas+3*64*1024*params.Argon2PerKiBGas; have != want {
+			t.Errorf("%s: gas mismatch: have %d, want %d", test.name, have, want)
+		}
+		if res, err := p.Run(in); err != nil {
+			t.Errorf("%s: seal check failed: %v", test.name, err)
+		} else if common.Bytes2Hex(res) != test.expected {
+			t.Errorf("%s: result mismatch: have %x, want %s", test.name, res, test.expected)
+		}
+	}
+}
+
+// Tests that the Tppow precompiles are only available from their fork block,
+// with the seal verification bound to the consensus engine of the chain.
+func TestTppowPrecompilesFork(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:        big.NewInt(1),
+		ByzantiumBlock: big.NewInt(0),
+		IstanbulBlock:  big.NewInt(0),
+		Tppow:          &params.TppowConfig{PrecompileBlock: big.NewInt(10)},
+	}
+	verify := func(*types.Header) error { return nil }
+	for _, tt := range []struct {
+		number uint64
+		active bool
+	}{{9, false}, {10, true}, {11, true}} {
+		evm := NewEVM(Context{BlockNumber: new(big.Int).SetUint64(tt.number), VerifySeal: verify}, nil, config, Config{})
+		if have := evm.precompile(common.BytesToAddress([]byte{10})) != nil; have != tt.active {
+			t.Errorf("block %d: argon2id active %v, want %v", tt.number, have, tt.active)
+		}
+		seal, _ := evm.precompile(tppowSealAddress).(*tppowSeal)
+		if have := seal != nil; have != tt.active {
+			t.Errorf("block %d: seal verification active %v, want %v", tt.number, have, tt.active)
+		}
+		if seal != nil && (seal.verify == nil || seal.config != config.Tppow) {
+			t.Errorf("block %d: seal verification not bound to the chain", tt.number)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/crypto"
 	"github.com/luck/go-luck/params"
 )
@@ -38,19 +39,15 @@ type (
 	// GetHashFunc returns the n'th block hash in the blockchain
 	// and is used by the BLOCKHASH EVM op code.
 	GetHashFunc func(uint64) common.Hash
+	// VerifySealFunc checks the proof-of-work seal of a header and is
+	// used by the seal verification precompile.
+	VerifySealFunc func(*types.Header) error
 )
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
 	if contract.CodeAddr != nil {
-		precompiles := PrecompiledContractsHomestead
-		if evm.chainRules.IsByzantium {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if evm.chainRules.IsIstanbul {
-			precompiles = PrecompiledContractsIstanbul
-		}
-		if p := precompiles[*contract.CodeAddr]; p != nil {
+		if p := evm.precompile(*contract.CodeAddr); p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
 	}
@@ -80,6 +77,8 @@ type Context struct {
 	Transfer TransferFunc
 	// GetHash returns the hash corresponding to n
 	GetHash GetHashFunc
+	// VerifySeal checks the proof-of-work seal of a header
+	VerifySeal VerifySealFunc
 
 	// Message information
 	Origin   common.Address // Provides information for ORIGIN
@@ -166,6 +165,25 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 	return evm
 }
 
+// precompile returns the precompiled contract at the given address under the
+// current chain rules, or nil if there is none.
+func (evm *EVM) precompile(addr common.Address) PrecompiledContract {
+	precompiles := PrecompiledContractsHomestead
+	if evm.chainRules.IsByzantium {
+		precompiles = PrecompiledContractsByzantium
+	}
+	if evm.chainRules.IsIstanbul {
+		precompiles = PrecompiledContractsIstanbul
+	}
+	if evm.chainRules.IsTppowPrecompiles {
+		if addr == tppowSealAddress {
+			return &tppowSeal{config: evm.chainConfig.Tppow, verify: evm.Context.VerifySeal}
+		}
+		precompiles = PrecompiledContractsTppow
+	}
+	return precompiles[addr]
+}
+
 // Cancel cancels any running EVM operation. This may be called concurrently and
 // it's safe to be called multiple times.
 func (evm *EVM) Cancel() {
@@ -205,14 +223,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		precompiles := PrecompiledContractsHomestead
-		if evm.chainRules.IsByzantium {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if evm.chainRules.IsIstanbul {
-			precompiles = PrecompiledContractsIstanbul
-		}
-		if precompiles[addr] == nil && evm.chainRules.IsEIP158 && value.Sign() == 0 {
+		if evm.precompile(addr) == nil && evm.chainRules.IsEIP158 && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
 				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
@@ -391,7 +402,11 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	nonce := evm.StateDB.GetNonce(caller.Address())
 	evm.StateDB.SetNonce(caller.Address(), nonce+1)
-
+	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
+	// the access-list change should not be rolled back
+	if evm.chainRules.IsBerlin {
+		evm.StateDB.AddAddressToAccessList(address)
+	}
 	// Ensure there's no existing contract already at the designated address
 	contractHash := evm.StateDB.GetCodeHash(address)
 	if evm.StateDB.GetNonce(address) != 0 || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
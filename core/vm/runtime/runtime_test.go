@@ -120,6 +120,40 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// TestEIP2929 checks that storage and account accesses are charged as cold on
+// first touch and warm afterwards once Berlin is active.
+func TestEIP2929(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.POP), // cold slot: 3 + 2100 + 2
+		byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.POP), // warm slot: 3 + 100 + 2
+		byte(vm.PUSH1), 0xff, byte(vm.EXTCODESIZE), byte(vm.POP), // cold account: 3 + 2600 + 2
+		byte(vm.PUSH1), 0xff, byte(vm.EXTCODESIZE), byte(vm.POP), // warm account: 3 + 100 + 2
+	}
+	for i, tt := range []struct {
+		berlin *big.Int
+		want   uint64
+	}{
+		{nil, 2 * (3 + 800 + 2 + 3 + 700 + 2)},
+		{new(big.Int), 3 + 2100 + 2 + 3 + 100 + 2 + 3 + 2600 + 2 + 3 + 100 + 2},
+	} {
+		config := *params.AllEthashProtocolChanges
+		config.BerlinBlock = tt.berlin
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		address := common.HexToAddress("0xaa")
+		statedb.SetCode(address, code)
+
+		gasLimit := uint64(100000)
+		_, left, err := Call(address, nil, &Config{ChainConfig: &config, State: statedb, GasLimit: gasLimit})
+		if err != nil {
+			t.Fatalf("test %d: call failed: %v", i, err)
+		}
+		if used := gasLimit - left; used != tt.want {
+			t.Errorf("test %d: gas used mismatch: have %d, want %d", i, used, tt.want)
+		}
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -261,8 +261,16 @@ func zeroBytes(bytes []byte) {
 	}
 }
 
+// Argon2Memory is the Argon2 memory size in KiB used by Argon2Hash.
+const Argon2Memory = 64 * 1024
+
 func Argon2Hash(data []byte, salt []byte) ([]byte) {
 	//fmt.Printf("argon2d salt=%v\r\n", salt)
-	key := argon2.IDKey(data, salt, 1, 64 * 1024, 4, 32)
-	return key
+	return Argon2HashWithMemory(data, salt, Argon2Memory)
+}
+
+// Argon2HashWithMemory calculates the 32 byte Argon2id hash of data, using the
+// given amount of memory in KiB.
+func Argon2HashWithMemory(data []byte, salt []byte, memory uint32) []byte {
+	return argon2.IDKey(data, salt, 1, memory, 4, 32)
 }
\ No newline at end of file
@@ -30,11 +30,15 @@ import (
 
 	"github.com/luck/go-luck/common"
 	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core"
 	"github.com/luck/go-luck/core/rawdb"
 	"github.com/luck/go-luck/core/state"
 	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/internal/fortapi"
+	"github.com/luck/go-luck/log"
+	"github.com/luck/go-luck/miner"
+	"github.com/luck/go-luck/params"
 	"github.com/luck/go-luck/rlp"
 	"github.com/luck/go-luck/rpc"
 	"github.com/luck/go-luck/trie"
@@ -154,6 +158,118 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return api.e.miner.HashRate()
 }
 
+// GetThreads returns the number of threads the consensus engine seals blocks
+// on. A negative value means local sealing is disabled.
+func (api *PrivateMinerAPI) GetThreads() int {
+	return api.e.Miner().Threads()
+}
+
+// TemplateTx is a transaction of a block template, along with the fee it pays.
+type TemplateTx struct {
+	Hash     common.Hash    `json:"hash"`
+	From     common.Address `json:"from"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	GasPrice *hexutil.Big   `json:"gasPrice"`
+	GasUsed  hexutil.Uint64 `json:"gasUsed"`
+	Fee      *hexutil.Big   `json:"fee"`
+	Raw      hexutil.Bytes  `json:"raw"`
+}
+
+// Template is the candidate block the miner prepared for sealing, along with
+// the fees and rewards sealing it would earn.
+type Template struct {
+	Header         *types.Header  `json:"header"`
+	SealHash       common.Hash    `json:"sealHash"`
+	Uncles         []common.Hash  `json:"uncles"`
+	Transactions   []*TemplateTx  `json:"transactions"`
+	Fees           *hexutil.Big   `json:"fees"`
+	CoinbaseReward *hexutil.Big   `json:"coinbaseReward"`
+	Author         common.Address `json:"author"`
+	AuthorReward   *hexutil.Big   `json:"authorReward"`
+}
+
+// newRPCTemplate converts a miner template into its RPC representation.
+func newRPCTemplate(config *params.ChainConfig, tmpl *miner.Template) (*Template, error) {
+	signer := types.MakeSigner(config, tmpl.Header.Number)
+
+	res := &Template{
+		Header:         tmpl.Header,
+		SealHash:       tmpl.SealHash,
+		Uncles:         make([]common.Hash, len(tmpl.Uncles)),
+		Transactions:   make([]*TemplateTx, len(tmpl.Transactions)),
+		Fees:           (*hexutil.Big)(tmpl.Fees),
+		CoinbaseReward: (*hexutil.Big)(tmpl.Reward),
+		Author:         tmpl.Author,
+		AuthorReward:   (*hexutil.Big)(tmpl.AuthorReward),
+	}
+	for i, uncle := range tmpl.Uncles {
+		res.Uncles[i] = uncle.Hash()
+	}
+	for i, tx := range tmpl.Transactions {
+		from, _ := types.Sender(signer, tx.Tx)
+		raw, err := tx.Tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		res.Transactions[i] = &TemplateTx{
+			Hash:     tx.Tx.Hash(),
+			From:     from,
+			Nonce:    hexutil.Uint64(tx.Tx.Nonce()),
+			GasPrice: (*hexutil.Big)(tx.Tx.GasPrice()),
+			GasUsed:  hexutil.Uint64(tx.GasUsed),
+			Fee:      (*hexutil.Big)(tx.Fee),
+			Raw:      raw,
+		}
+	}
+	return res, nil
+}
+
+// GetTemplate returns the candidate block currently prepared for sealing, with
+// the consensus fields of its header populated, the fees of its transactions
+// and the rewards sealing it would earn.
+func (api *PrivateMinerAPI) GetTemplate() (*Template, error) {
+	tmpl := api.e.Miner().PendingTemplate()
+	if tmpl == nil {
+		return nil, errors.New("no block template available yet")
+	}
+	return newRPCTemplate(api.e.BlockChain().Config(), tmpl)
+}
+
+// Template creates a subscription that fires with the new block template every
+// time the miner commits new work.
+func (api *PrivateMinerAPI) Template(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		templates := make(chan *miner.Template, 16)
+		templatesSub := api.e.Miner().SubscribeTemplates(templates)
+		defer templatesSub.Unsubscribe()
+
+		config := api.e.BlockChain().Config()
+		for {
+			select {
+			case tmpl := <-templates:
+				res, err := newRPCTemplate(config, tmpl)
+				if err != nil {
+					log.Warn("Failed to encode block template", "number", tmpl.Header.Number, "err", err)
+					continue
+				}
+				notifier.Notify(rpcSub.ID, res)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PrivateAdminAPI is the collection of Luck full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -323,9 +439,36 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
-	Hash  common.Hash            `json:"hash"`
-	Block map[string]interface{} `json:"block"`
-	RLP   string                 `json:"rlp"`
+	Hash   common.Hash            `json:"hash"`
+	Block  map[string]interface{} `json:"block"`
+	RLP    string                 `json:"rlp"`
+	Reason *BadBlockReason        `json:"reason,omitempty"`
+}
+
+// BadBlockReason describes why a bad block was rejected. Failed consensus checks
+// of the two-stage proof-of-work also report the offending and expected values.
+type BadBlockReason struct {
+	Error string       `json:"error"`
+	Check string       `json:"check,omitempty"`
+	Have  *hexutil.Big `json:"have,omitempty"`
+	Want  *hexutil.Big `json:"want,omitempty"`
+}
+
+// newBadBlockReason converts the error a bad block was rejected with into its
+// RPC representation.
+func newBadBlockReason(err error) *BadBlockReason {
+	if err == nil {
+		return nil
+	}
+	reason := &BadBlockReason{Error: err.Error()}
+
+	var sealErr *tppow.SealError
+	if errors.As(err, &sealErr) {
+		reason.Check = sealErr.Err.Error()
+		reason.Have = (*hexutil.Big)(sealErr.Have)
+		reason.Want = (*hexutil.Big)(sealErr.Want)
+	}
+	return reason
 }
 
 // GetBadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
@@ -337,7 +480,8 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	var err error
 	for i, block := range blocks {
 		results[i] = &BadBlockArgs{
-			Hash: block.Hash(),
+			Hash:   block.Hash(),
+			Reason: newBadBlockReason(api.fort.BlockChain().BadBlockReason(block.Hash())),
 		}
 		if rlpBytes, err := rlp.EncodeToBytes(block); err != nil {
 			results[i].RLP = err.Error() // Hacky, but hey, it works
@@ -224,6 +224,10 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.fort.txPool.AddLocal(signedTx)
 }
 
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return b.fort.txPool.AddPrivate(signedTx)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending, err := b.fort.txPool.Pending()
 	if err != nil {
@@ -257,6 +261,18 @@ func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.fort.TxPool().Content()
 }
 
+func (b *EthAPIBackend) TxPoolQueueReasons() map[common.Address]map[uint64]core.QueueReason {
+	return b.fort.TxPool().QueueReasons()
+}
+
+func (b *EthAPIBackend) TxPoolExport() (types.Transactions, error) {
+	return b.fort.TxPool().Export(), nil
+}
+
+func (b *EthAPIBackend) TxPoolImport(txs types.Transactions) ([]error, error) {
+	return b.fort.TxPool().AddRemotesSync(txs), nil
+}
+
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.fort.TxPool().SubscribeNewTxsEvent(ch)
 }
@@ -18,6 +18,7 @@ package fort
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -26,9 +27,13 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core/rawdb"
 	"github.com/luck/go-luck/core/state"
+	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/crypto"
+	"github.com/luck/go-luck/miner"
+	"github.com/luck/go-luck/params"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -201,3 +206,52 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestNewBadBlockReason(t *testing.T) {
+	if reason := newBadBlockReason(nil); reason != nil {
+		t.Errorf("reason for nil error: have %v, want nil", reason)
+	}
+	plain := newBadBlockReason(errors.New("boom"))
+	if plain.Error != "boom" || plain.Check != "" || plain.Have != nil {
+		t.Errorf("plain error reason mismatch: have %+v", plain)
+	}
+	err := fmt.Errorf("wrapped: %w", &tppow.SealError{Err: tppow.ErrBasisMismatch, Have: big.NewInt(1), Want: big.NewInt(2)})
+	reason := newBadBlockReason(err)
+	if reason.Check != tppow.ErrBasisMismatch.Error() {
+		t.Errorf("check mismatch: have %q, want %q", reason.Check, tppow.ErrBasisMismatch.Error())
+	}
+	if reason.Have.ToInt().Int64() != 1 || reason.Want.ToInt().Int64() != 2 {
+		t.Errorf("values mismatch: have %v/%v, want 1/2", reason.Have, reason.Want)
+	}
+}
+
+// Tests that block templates carry the canonical encoding of their transactions,
+// typed ones included.
+func TestRPCTemplateRawTransactions(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	txs := []*types.Transaction{
+		types.NewTransaction(0, to, big.NewInt(1), params.TxGas, big.NewInt(1), nil),
+		types.NewTx(&types.AccessListTx{
+			ChainID:    params.TestChainConfig.ChainID,
+			Nonce:      1,
+			To:         &to,
+			Gas:        params.TxGas,
+			GasPrice:   big.NewInt(1),
+			AccessList: types.AccessList{{Address: to}},
+		}),
+	}
+	tmpl := &miner.Template{Header: &types.Header{Number: big.NewInt(1)}}
+	for _, tx := range txs {
+		tmpl.Transactions = append(tmpl.Transactions, &miner.TemplateTx{Tx: tx, Fee: new(big.Int)})
+	}
+	res, err := newRPCTemplate(params.TestChainConfig, tmpl)
+	if err != nil {
+		t.Fatalf("failed to convert template: %v", err)
+	}
+	for i, tx := range txs {
+		want, _ := tx.MarshalBinary()
+		if !bytes.Equal(res.Transactions[i].Raw, want) {
+			t.Errorf("tx %d: raw encoding mismatch: have %x, want %x", i, res.Transactions[i].Raw, want)
+		}
+	}
+}
@@ -202,6 +202,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Luck, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = ctx.ResolvePath(config.TxPool.Snapshot)
+	}
 	fort.txPool = core.NewTxPool(config.TxPool, chainConfig, fort.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -251,36 +254,25 @@ func makeExtraData(extra []byte) []byte {
 // CreateConsensusEngine creates the required type of consensus engine instance for an Luck service
 func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainConfig, config *ethash.Config, notify []string, noverify bool, db fortdb.Database) consensus.Engine {
 	// If proof-of-authority is requested, set it up
-	// if chainConfig.Clique != nil {
-	// 	return clique.New(chainConfig.Clique, db)
-	// }
-	// // Otherwise assume proof-of-work
-	// switch config.PowMode {
-	// case ethash.ModeFake:
-	// 	log.Warn("Ethash used in fake mode")
-	// 	return ethash.NewFaker()
-	// case ethash.ModeTest:
-	// 	log.Warn("Ethash used in test mode")
-	// 	return ethash.NewTester(nil, noverify)
-	// case ethash.ModeShared:
-	// 	log.Warn("Ethash used in shared mode")
-	// 	return ethash.NewShared()
-	// default:
-	// 	engine := ethash.New(ethash.Config{
-	// 		CacheDir:         ctx.ResolvePath(config.CacheDir),
-	// 		CachesInMem:      config.CachesInMem,
-	// 		CachesOnDisk:     config.CachesOnDisk,
-	// 		CachesLockMmap:   config.CachesLockMmap,
-	// 		DatasetDir:       config.DatasetDir,
-	// 		DatasetsInMem:    config.DatasetsInMem,
-	// 		DatasetsOnDisk:   config.DatasetsOnDisk,
-	// 		DatasetsLockMmap: config.DatasetsLockMmap,
-	// 	}, notify, noverify)
-	// 	engine.SetThreads(-1) // Disable CPU mining
-	// 	return engine
-	// }
-
-	return tppow.New()
+	if chainConfig.Clique != nil {
+		return clique.New(chainConfig.Clique, db)
+	}
+	// Otherwise assume two-stage proof-of-work
+	switch config.PowMode {
+	case ethash.ModeFake:
+		log.Warn("Tppow used in fake mode")
+		return tppow.NewFakerWithConfig(chainConfig.Tppow)
+	case ethash.ModeFullFake:
+		log.Warn("Tppow used in full fake mode")
+		return tppow.NewFullFakerWithConfig(chainConfig.Tppow)
+	case ethash.ModeTest, ethash.ModeShared:
+		// Tppow has no reduced size seals, fake them instead of running the
+		// full Argon2 proof-of-work where a quick test chain is expected
+		log.Warn("Tppow used in fake mode for testing")
+		return tppow.NewFakerWithConfig(chainConfig.Tppow)
+	default:
+		return tppow.New(chainConfig.Tppow, notify, noverify)
+	}
 }
 
 // APIs return the collection of RPC services the luck package offers.
@@ -444,16 +436,12 @@ func (s *Luck) SetEtherbase(forterbase common.Address) {
 // and updates the minimum price required by the transaction pool.
 func (s *Luck) StartMining(threads int) error {
 	// Update the thread count within the consensus engine
-	type threaded interface {
-		SetThreads(threads int)
-	}
-	if th, ok := s.engine.(threaded); ok {
-		log.Info("Updated mining threads", "threads", threads)
-		if threads == 0 {
-			threads = -1 // Disable the miner from within
-		}
-		th.SetThreads(threads)
+	log.Info("Updated mining threads", "threads", threads)
+	if threads == 0 {
+		threads = -1 // Disable the miner from within
 	}
+	s.miner.SetThreads(threads)
+
 	// If the miner was not running, initialize it
 	if !s.IsMining() {
 		// Propagate the initial price point to the transaction pool
@@ -489,12 +477,8 @@ func (s *Luck) StartMining(threads int) error {
 // at the block creation level.
 func (s *Luck) StopMining() {
 	// Update the thread count within the consensus engine
-	type threaded interface {
-		SetThreads(threads int)
-	}
-	if th, ok := s.engine.(threaded); ok {
-		th.SetThreads(-1)
-	}
+	s.miner.SetThreads(-1)
+
 	// Stop the block creating itself
 	s.miner.Stop()
 }
@@ -0,0 +1,77 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package fort
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/core"
+	"github.com/luck/go-luck/core/rawdb"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/core/vm"
+	"github.com/luck/go-luck/params"
+	"github.com/luck/go-luck/rlp"
+)
+
+// Tests that the ethash test modes map to a fake Tppow engine instead of the
+// full proof-of-work.
+func TestCreateConsensusEngineModes(t *testing.T) {
+	// An unsealed header only passes the seal check of fake engines
+	header := &types.Header{
+		Number:          big.NewInt(1),
+		Lucky:           new(big.Int),
+		Basis:           big.NewInt(1),
+		DifficultyAlpha: new(big.Int),
+		DifficultyBeta:  new(big.Int),
+		Difficulty:      new(big.Int),
+	}
+	tests := []struct {
+		mode ethash.Mode
+		fake bool
+	}{
+		{ethash.ModeNormal, false},
+		{ethash.ModeShared, true},
+		{ethash.ModeTest, true},
+		{ethash.ModeFake, true},
+		{ethash.ModeFullFake, true},
+	}
+	for i, tt := range tests {
+		engine := CreateConsensusEngine(nil, params.TestChainConfig, &ethash.Config{PowMode: tt.mode}, nil, false, rawdb.NewMemoryDatabase())
+		err := engine.VerifySeal(nil, header)
+		if fake := err == nil; fake != tt.fake {
+			t.Errorf("test %d: fake engine mismatch: have %v, want %v (err %v)", i, fake, tt.fake, err)
+		}
+		engine.Close()
+	}
+}
+
+// Tests that verifying the seal of a main network header in the EVM costs only a
+// fraction of the default block gas limit, so contracts checking a few headers
+// still fit into blocks built by the miner.
+func TestTppowSealGasCeil(t *testing.T) {
+	input, err := rlp.EncodeToBytes(core.DefaultGenesisBlock().ToBlock(nil).Header())
+	if err != nil {
+		t.Fatalf("failed to encode header: %v", err)
+	}
+	gas := vm.PrecompiledContractsTppow[common.BytesToAddress([]byte{11})].RequiredGas(input)
+	if limit := DefaultConfig.Miner.GasCeil / 4; gas > limit {
+		t.Errorf("seal check too expensive: have %d gas, want at most %d", gas, limit)
+	}
+}
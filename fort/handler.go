@@ -763,9 +763,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			} else if err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
-			// Retrieve the requested transaction, skipping if unknown to us
+			// Retrieve the requested transaction, skipping if unknown to us or private
 			tx := pm.txpool.Get(hash)
-			if tx == nil {
+			if tx == nil || pm.txpool.IsPrivate(hash) {
 				continue
 			}
 			// If known, encode and queue for response packet
@@ -838,12 +838,15 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 }
 
 // BroadcastTransactions will propagate a batch of transactions to all peers which are not known to
-// already have the given transaction.
+// already have the given transaction. Transactions marked private in the pool are never sent.
 func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propagate bool) {
 	var (
 		txset = make(map[*peer][]common.Hash)
 		annos = make(map[*peer][]common.Hash)
 	)
+	// Withhold any private transactions from the network
+	txs = pm.publicTransactions(txs)
+
 	// Broadcast transactions to a batch of peers not knowing about it
 	if propagate {
 		for _, tx := range txs {
@@ -877,6 +880,20 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 	}
 }
 
+// publicTransactions filters out all the transactions the pool marks as private,
+// which must not be propagated to the network.
+func (pm *ProtocolManager) publicTransactions(txs types.Transactions) types.Transactions {
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if pm.txpool.IsPrivate(tx.Hash()) {
+			log.Trace("Withholding private transaction", "hash", tx.Hash())
+			continue
+		}
+		public = append(public, tx)
+	}
+	return public
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	defer pm.wg.Done()
@@ -114,6 +114,10 @@ type txPool interface {
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// IsPrivate returns whether the transaction with the given hash must
+	// be withheld from network propagation.
+	IsPrivate(hash common.Hash) bool
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -415,6 +415,65 @@ loop:
 }
 
 // Tests that the custom union field encoder and decoder works correctly.
+// Tests that transactions marked private in the pool are neither propagated nor
+// announced to the connected peers.
+func TestPrivateTransactionWithholding(t *testing.T) {
+	// Create a protocol manager for transaction fetcher and sender
+	pmFetcher, _ := newTestProtocolManagerMust(t, downloader.FastSync, 0, nil, nil)
+	defer pmFetcher.Stop()
+	pmSender, _ := newTestProtocolManagerMust(t, downloader.FastSync, 1024, nil, nil)
+	defer pmSender.Stop()
+
+	// Sync up the two peers
+	io1, io2 := p2p.MsgPipe()
+
+	go pmSender.handle(pmSender.newPeer(65, p2p.NewPeer(lnode.ID{}, "sender", nil), io2, pmSender.txpool.Get))
+	go pmFetcher.handle(pmFetcher.newPeer(65, p2p.NewPeer(lnode.ID{}, "fetcher", nil), io1, pmFetcher.txpool.Get))
+
+	time.Sleep(250 * time.Millisecond)
+	pmFetcher.doSync(peerToSyncOp(downloader.FullSync, pmFetcher.peers.BestPeer()))
+	atomic.StoreUint32(&pmFetcher.acceptTxs, 1)
+
+	newTxs := make(chan core.NewTxsEvent, 1024)
+	sub := pmFetcher.txpool.SubscribeNewTxsEvent(newTxs)
+	defer sub.Unsubscribe()
+
+	// Fill the pool with new transactions, marking every second one private
+	var (
+		alltxs  = make([]*types.Transaction, 64)
+		private = make(map[common.Hash]bool)
+	)
+	for nonce := range alltxs {
+		alltxs[nonce] = newTestTransaction(testAccount, uint64(nonce), 0)
+		if nonce%2 == 0 {
+			private[alltxs[nonce].Hash()] = true
+		}
+	}
+	pmSender.txpool.(*testTxPool).private = private
+	pmSender.txpool.AddRemotes(alltxs)
+
+	var got int
+	timeout := time.NewTimer(time.Second)
+	defer timeout.Stop()
+loop:
+	for {
+		select {
+		case ev := <-newTxs:
+			for _, tx := range ev.Txs {
+				if private[tx.Hash()] {
+					t.Fatalf("private transaction %x propagated", tx.Hash())
+				}
+			}
+			got += len(ev.Txs)
+		case <-timeout.C:
+			break loop
+		}
+	}
+	if got != len(alltxs)-len(private) {
+		t.Fatalf("public transaction count mismatch: have %d, want %d", got, len(alltxs)-len(private))
+	}
+}
+
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
 	var hash common.Hash
@@ -288,6 +288,8 @@ func (cw *contractWrapper) pushObject(vm *duktape.Context) {
 type Tracer struct {
 	inited bool // Flag whforter the context was already inited from the EVM
 
+	activePrecompiles []common.Address // Precompiles active at the traced block, inited from the EVM
+
 	vm *duktape.Context // Javascript VM instance
 
 	tracerObject int // Stack index of the tracer JavaScript object
@@ -399,8 +401,14 @@ func New(code string) (*Tracer, error) {
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("isPrecompiled", func(ctx *duktape.Context) int {
-		_, ok := vm.PrecompiledContractsIstanbul[common.BytesToAddress(popSlice(ctx))]
-		ctx.PushBoolean(ok)
+		addr := common.BytesToAddress(popSlice(ctx))
+		for _, p := range tracer.activePrecompiles {
+			if p == addr {
+				ctx.PushBoolean(true)
+				return 1
+			}
+		}
+		ctx.PushBoolean(false)
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("slice", func(ctx *duktape.Context) int {
@@ -546,6 +554,7 @@ func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 		// Initialize the context if it wasn't done yet
 		if !jst.inited {
 			jst.ctx["block"] = env.BlockNumber.Uint64()
+			jst.activePrecompiles = vm.ActivePrecompiles(env.ChainConfig().Rules(env.BlockNumber))
 			jst.inited = true
 		}
 		// If tracing was interrupted, set the error and stop
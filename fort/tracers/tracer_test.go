@@ -178,3 +178,35 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Errorf("Expected timeout error, got %v", err)
 	}
 }
+
+// Tests that tracers only report the Tppow precompiles as such once they are
+// active at the traced block.
+func TestIsPrecompiledTppow(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Tppow = &params.TppowConfig{PrecompileBlock: big.NewInt(10)}
+
+	for _, tt := range []struct {
+		number uint64
+		want   string
+	}{{9, "[true,false,false]"}, {10, "[true,true,true]"}} {
+		// Feed raw addresses as toAddress would truncate them to 20 bytes
+		tracer, err := New(`{res: null, step: function() { if (this.res == null) { this.res = ["09", "0a", "0b"].map(function(id) { return isPrecompiled(Duktape.dec("hex", id)); }); } }, fault: function() {}, result: function() { return this.res; }}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := vm.NewEVM(vm.Context{BlockNumber: new(big.Int).SetUint64(tt.number)}, &dummyStatedb{}, &config, vm.Config{Debug: true, Tracer: tracer})
+		contract := vm.NewContract(account{}, account{}, big.NewInt(0), 10000)
+		contract.Code = []byte{byte(vm.PUSH1), 0x1, 0x0}
+
+		if _, err := env.Interpreter().Run(contract, []byte{}, false); err != nil {
+			t.Fatal(err)
+		}
+		ret, err := tracer.GetResult()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(ret) != tt.want {
+			t.Errorf("block %d: precompiles mismatch: have %s, want %s", tt.number, ret, tt.want)
+		}
+	}
+}
@@ -28,7 +28,6 @@ import (
 	"github.com/luck/go-luck/common"
 	"github.com/luck/go-luck/common/hexutil"
 	"github.com/luck/go-luck/core/types"
-	"github.com/luck/go-luck/rlp"
 	"github.com/luck/go-luck/rpc"
 )
 
@@ -510,7 +509,7 @@ func (ec *Client) EstimateGas(ctx context.Context, msg luck.CallMsg) (uint64, er
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -534,5 +533,8 @@ func toCallArg(msg luck.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
@@ -27,7 +27,9 @@ import (
 
 	"github.com/luck/go-luck"
 	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
 	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core"
 	"github.com/luck/go-luck/core/rawdb"
 	"github.com/luck/go-luck/core/types"
@@ -206,7 +208,7 @@ func generateTestChain() (*core.Genesis, []*types.Block) {
 		g.SetExtra([]byte("test"))
 	}
 	gblock := genesis.ToBlock(db)
-	engine := ethash.NewFaker()
+	engine := tppow.NewFaker()
 	blocks, _ := core.GenerateChain(config, gblock, engine, db, 1, generate)
 	blocks = append([]*types.Block{gblock}, blocks...)
 	return genesis, blocks
@@ -246,8 +248,13 @@ func TestHeader(t *testing.T) {
 			if tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()) {
 				t.Fatalf("HeaderByNumber(%v) error = %q, want %q", tt.block, err, tt.wantErr)
 			}
-			if got != nil && got.Number.Sign() == 0 {
-				got.Number = big.NewInt(0) // hack to make DeepEqual work
+			if got != nil {
+				// hack to make DeepEqual work
+				for _, n := range []**big.Int{&got.Number, &got.Lucky, &got.Basis, &got.DifficultyAlpha, &got.DifficultyBeta} {
+					if *n != nil && (*n).Sign() == 0 {
+						*n = big.NewInt(0)
+					}
+				}
 			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Fatalf("HeaderByNumber(%v)\n   = %v\nwant %v", tt.block, got, tt.want)
@@ -256,6 +263,58 @@ func TestHeader(t *testing.T) {
 	}
 }
 
+// Tests that every Tppow header field survives the RPC JSON round-trip.
+func TestHeaderTppowFields(t *testing.T) {
+	backend, chain := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	ec := NewClient(client)
+	want := chain[1].Header()
+
+	have, err := ec.HeaderByHash(context.Background(), want.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve header: %v", err)
+	}
+	for _, field := range []struct {
+		name       string
+		have, want *big.Int
+	}{
+		{"lucky", have.Lucky, want.Lucky},
+		{"basis", have.Basis, want.Basis},
+		{"difficultyAlpha", have.DifficultyAlpha, want.DifficultyAlpha},
+		{"difficultyBeta", have.DifficultyBeta, want.DifficultyBeta},
+		{"difficulty", have.Difficulty, want.Difficulty},
+	} {
+		if field.want == nil {
+			t.Fatalf("%s: test header field unset", field.name)
+		}
+		if field.have == nil || field.have.Cmp(field.want) != 0 {
+			t.Errorf("%s mismatch: have %v, want %v", field.name, field.have, field.want)
+		}
+	}
+	if have.FirstNonce != want.FirstNonce {
+		t.Errorf("firstNonce mismatch: have %x, want %x", have.FirstNonce, want.FirstNonce)
+	}
+	if have.SecondNonce != want.SecondNonce {
+		t.Errorf("secondNonce mismatch: have %x, want %x", have.SecondNonce, want.SecondNonce)
+	}
+	if have.Hash() != want.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", have.Hash(), want.Hash())
+	}
+	// Existing consumers read the luck from its original key
+	var raw map[string]interface{}
+	if err := client.CallContext(context.Background(), &raw, "fort_getBlockByHash", want.Hash(), false); err != nil {
+		t.Fatalf("failed to retrieve raw block: %v", err)
+	}
+	for _, key := range []string{"luck", "lucky"} {
+		if raw[key] != hexutil.EncodeBig(want.Lucky) {
+			t.Errorf("%s key mismatch: have %v, want %v", key, raw[key], hexutil.EncodeBig(want.Lucky))
+		}
+	}
+}
+
 func TestBalanceAt(t *testing.T) {
 	backend, _ := newTestBackend(t)
 	client, _ := backend.Attach()
@@ -33,6 +33,7 @@ import (
 	"github.com/luck/go-luck/common"
 	"github.com/luck/go-luck/common/mclock"
 	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core"
 	"github.com/luck/go-luck/core/types"
 	"github.com/luck/go-luck/fort"
@@ -40,6 +41,7 @@ import (
 	"github.com/luck/go-luck/les"
 	"github.com/luck/go-luck/log"
 	"github.com/luck/go-luck/p2p"
+	"github.com/luck/go-luck/params"
 	"github.com/luck/go-luck/rpc"
 	"github.com/gorilla/websocket"
 )
@@ -73,6 +75,7 @@ type Service struct {
 	fort    *fort.Luck      // Full Luck service if monitoring a full node
 	les    *les.LightLuck // Light Luck service if monitoring a light node
 	engine consensus.Engine   // Consensus engine to retrieve variadic block fields
+	tppow  bool               // Whether to extend the reports with Tppow seal details
 
 	node string // Name of the node to display on the monitoring page
 	pass string // Password to authorize access to the monitoring page
@@ -82,8 +85,10 @@ type Service struct {
 	histCh chan []uint64 // History request block numbers are fed into this channel
 }
 
-// New returns a monitoring service ready for stats reporting.
-func New(url string, fortServ *fort.Luck, lesServ *les.LightLuck) (*Service, error) {
+// New returns a monitoring service ready for stats reporting. If tppow is set,
+// block and node reports are extended with the Tppow seal details, which only
+// netstats servers aware of them are able to display.
+func New(url string, tppow bool, fortServ *fort.Luck, lesServ *les.LightLuck) (*Service, error) {
 	// Parse the netstats connection url
 	re := regexp.MustCompile("([^:@]*)(:([^@]*))?@(.+)")
 	parts := re.FindStringSubmatch(url)
@@ -101,6 +106,7 @@ func New(url string, fortServ *fort.Luck, lesServ *les.LightLuck) (*Service, err
 		fort:    fortServ,
 		les:    lesServ,
 		engine: engine,
+		tppow:  tppow,
 		node:   parts[1],
 		pass:   parts[3],
 		host:   parts[4],
@@ -482,6 +488,20 @@ type blockStats struct {
 	TxHash     common.Hash    `json:"transactionsRoot"`
 	Root       common.Hash    `json:"stateRoot"`
 	Uncles     uncleStats     `json:"uncles"`
+	Tppow      *tppowStats    `json:"tppow,omitempty"`
+}
+
+// tppowStats is the information to report about the two-stage proof-of-work of
+// individual blocks, if enabled.
+type tppowStats struct {
+	Lucky           string           `json:"lucky"`
+	Basis           string           `json:"basis"`
+	DifficultyAlpha string           `json:"difficultyAlpha"`
+	DifficultyBeta  string           `json:"difficultyBeta"`
+	FirstNonce      types.BlockNonce `json:"firstNonce"`
+	SecondNonce     types.BlockNonce `json:"secondNonce"`
+	BlockTime       uint64           `json:"blockTime"`       // Seconds elapsed since the parent block
+	TargetBlockTime uint64           `json:"targetBlockTime"` // Seconds between blocks the difficulty retargets towards
 }
 
 // txStats is the information to report about individual transactions.
@@ -524,6 +544,8 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 	// Gather the block infos from the local blockchain
 	var (
 		header *types.Header
+		parent *types.Header
+		config *params.ChainConfig
 		td     *big.Int
 		txs    []txStats
 		uncles []*types.Header
@@ -535,6 +557,10 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		}
 		header = block.Header()
 		td = s.fort.BlockChain().GetTd(header.Hash(), header.Number.Uint64())
+		if s.tppow && header.Number.Sign() > 0 {
+			parent = s.fort.BlockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		}
+		config = s.fort.BlockChain().Config()
 
 		txs = make([]txStats, len(block.Transactions()))
 		for i, tx := range block.Transactions() {
@@ -549,11 +575,30 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 			header = s.les.BlockChain().CurrentHeader()
 		}
 		td = s.les.BlockChain().GetTd(header.Hash(), header.Number.Uint64())
+		if s.tppow && header.Number.Sign() > 0 {
+			parent = s.les.BlockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		}
+		config = s.les.BlockChain().Config()
 		txs = []txStats{}
 	}
 	// Assemble and return the block stats
 	author, _ := s.engine.Author(header)
 
+	var seal *tppowStats
+	if s.tppow {
+		seal = &tppowStats{
+			Lucky:           bigString(header.Lucky),
+			Basis:           bigString(header.Basis),
+			DifficultyAlpha: bigString(header.DifficultyAlpha),
+			DifficultyBeta:  bigString(header.DifficultyBeta),
+			FirstNonce:      header.FirstNonce,
+			SecondNonce:     header.SecondNonce,
+			TargetBlockTime: config.Tppow.WithDefaults().TargetBlockTime,
+		}
+		if parent != nil {
+			seal.BlockTime = header.Time - parent.Time
+		}
+	}
 	return &blockStats{
 		Number:     header.Number,
 		Hash:       header.Hash(),
@@ -568,7 +613,16 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		TxHash:     header.TxHash,
 		Root:       header.Root,
 		Uncles:     uncles,
+		Tppow:      seal,
+	}
+}
+
+// bigString formats an optional header field, reporting unset ones as zero.
+func bigString(n *big.Int) string {
+	if n == nil {
+		return "0"
 	}
+	return n.String()
 }
 
 // reportHistory retrieves the most recent batch of blocks and reports it to the
@@ -671,6 +725,9 @@ type nodeStats struct {
 	Peers    int  `json:"peers"`
 	GasPrice int  `json:"gasPrice"`
 	Uptime   int  `json:"uptime"`
+
+	LuckHashrate  *int `json:"luckHashrate,omitempty"`  // First stage hashrate, if Tppow reports are enabled
+	BlockHashrate *int `json:"blockHashrate,omitempty"` // Second stage hashrate, if Tppow reports are enabled
 }
 
 // reportPending retrieves various stats about the node at the networking and
@@ -699,17 +756,25 @@ func (s *Service) reportStats(conn *websocket.Conn) error {
 	// Assemble the node stats and send it to the server
 	log.Trace("Sending node details to fortstats")
 
+	details := &nodeStats{
+		Active:   true,
+		Mining:   mining,
+		Hashrate: hashrate,
+		Peers:    s.server.PeerCount(),
+		GasPrice: gasprice,
+		Syncing:  syncing,
+		Uptime:   100,
+	}
+	if s.tppow {
+		var luck, block int
+		if engine, ok := s.engine.(*tppow.Tppow); ok {
+			luck, block = int(engine.LuckHashrate()), int(engine.BlockHashrate())
+		}
+		details.LuckHashrate, details.BlockHashrate = &luck, &block
+	}
 	stats := map[string]interface{}{
-		"id": s.node,
-		"stats": &nodeStats{
-			Active:   true,
-			Mining:   mining,
-			Hashrate: hashrate,
-			Peers:    s.server.PeerCount(),
-			GasPrice: gasprice,
-			Syncing:  syncing,
-			Uptime:   100,
-		},
+		"id":    s.node,
+		"stats": details,
 	}
 	report := map[string][]interface{}{
 		"emit": {"stats", stats},
@@ -20,6 +20,8 @@ package graphql
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/luck/go-luck"
@@ -35,8 +37,12 @@ import (
 	"github.com/luck/go-luck/rpc"
 )
 
+// maxHeaderRange is the maximum number of blocks returned by a headers query.
+const maxHeaderRange = 1000
+
 var (
 	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+	errHeaderRange    = fmt.Errorf("header range exceeds %d blocks", maxHeaderRange)
 )
 
 // Account represents an Luck account at a particular block.
@@ -493,6 +499,63 @@ func (b *Block) Nonce(ctx context.Context) (hexutil.Bytes, error) {
 	return hexutil.Bytes(header.Nonce[:]), nil
 }
 
+func (b *Block) FirstNonce(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(header.FirstNonce[:]), nil
+}
+
+func (b *Block) SecondNonce(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return hexutil.Bytes(header.SecondNonce[:]), nil
+}
+
+func (b *Block) Lucky(ctx context.Context) (hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return bigOrZero(header.Lucky), nil
+}
+
+func (b *Block) Basis(ctx context.Context) (hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return bigOrZero(header.Basis), nil
+}
+
+func (b *Block) DifficultyAlpha(ctx context.Context) (hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return bigOrZero(header.DifficultyAlpha), nil
+}
+
+func (b *Block) DifficultyBeta(ctx context.Context) (hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return bigOrZero(header.DifficultyBeta), nil
+}
+
+// bigOrZero converts a header field to its GraphQL form, treating unset fields
+// (e.g. of the genesis block) as zero.
+func bigOrZero(n *big.Int) hexutil.Big {
+	if n == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*n)
+}
+
 func (b *Block) MixHash(ctx context.Context) (common.Hash, error) {
 	header, err := b.resolveHeader(ctx)
 	if err != nil {
@@ -956,6 +1019,51 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	return ret, nil
 }
 
+func (r *Resolver) Headers(ctx context.Context, args struct {
+	From hexutil.Uint64
+	To   *hexutil.Uint64
+}) ([]*Block, error) {
+	from := rpc.BlockNumber(args.From)
+
+	head := rpc.BlockNumber(r.backend.CurrentBlock().Number().Int64())
+	to := head
+	if args.To != nil && rpc.BlockNumber(*args.To) < head {
+		to = rpc.BlockNumber(*args.To)
+	}
+	if to < from {
+		return []*Block{}, nil
+	}
+	if to-from >= maxHeaderRange {
+		return nil, errHeaderRange
+	}
+	// Walk the range backwards along the parent hashes, so that only the last
+	// header needs a number lookup and the rest are fetched by hash
+	header, err := r.backend.HeaderByNumber(ctx, to)
+	if err != nil || header == nil {
+		return []*Block{}, err
+	}
+	ret := make([]*Block, to-from+1)
+	for i := len(ret) - 1; i >= 0 && header != nil; i-- {
+		numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(header.Number.Uint64()))
+		ret[i] = &Block{
+			backend:      r.backend,
+			numberOrHash: &numberOrHash,
+			hash:         header.Hash(),
+			header:       header,
+		}
+		if i > 0 {
+			if header, err = r.backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Ancestors missing from the database are left out
+	for len(ret) > 0 && ret[0] == nil {
+		ret = ret[1:]
+	}
+	return ret, nil
+}
+
 func (r *Resolver) Pending(ctx context.Context) *Pending {
 	return &Pending{r.backend}
 }
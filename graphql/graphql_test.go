@@ -17,7 +17,20 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
 	"testing"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/internal/fortapi"
+	"github.com/luck/go-luck/rpc"
+	"github.com/graph-gophers/graphql-go"
 )
 
 func TestBuildSchema(t *testing.T) {
@@ -26,3 +39,194 @@ func TestBuildSchema(t *testing.T) {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
 }
+
+// headerBackend is a backend serving a chain of headers from memory, the rest of
+// the backend methods are left unimplemented.
+type headerBackend struct {
+	fortapi.Backend
+
+	headers []*types.Header
+	hashes  map[common.Hash]*types.Header
+}
+
+// newHeaderBackend creates a backend with a chain of n+1 headers, each carrying
+// Tppow seal fields derived from its number.
+func newHeaderBackend(n int) *headerBackend {
+	b := &headerBackend{hashes: make(map[common.Hash]*types.Header)}
+	var parent common.Hash
+	for i := 0; i <= n; i++ {
+		header := &types.Header{
+			ParentHash:      parent,
+			Number:          big.NewInt(int64(i)),
+			Difficulty:      big.NewInt(int64(i)),
+			Lucky:           big.NewInt(int64(10 * i)),
+			Basis:           big.NewInt(int64(20 * i)),
+			DifficultyAlpha: big.NewInt(int64(30 * i)),
+			DifficultyBeta:  big.NewInt(int64(40 * i)),
+			FirstNonce:      types.EncodeNonce(uint64(50 * i)),
+			SecondNonce:     types.EncodeNonce(uint64(60 * i)),
+		}
+		parent = header.Hash()
+		b.headers = append(b.headers, header)
+		b.hashes[parent] = header
+	}
+	return b
+}
+
+// drop removes a header from the backend, as if it was missing from the database.
+func (b *headerBackend) drop(number int) {
+	delete(b.hashes, b.headers[number].Hash())
+	b.headers[number] = nil
+}
+
+func (b *headerBackend) CurrentBlock() *types.Block {
+	return types.NewBlockWithHeader(b.headers[len(b.headers)-1])
+}
+
+func (b *headerBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number < 0 || int(number) >= len(b.headers) {
+		return nil, nil
+	}
+	return b.headers[number], nil
+}
+
+func (b *headerBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.hashes[hash], nil
+}
+
+func (b *headerBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return b.HeaderByHash(ctx, hash)
+	}
+	number, _ := blockNrOrHash.Number()
+	return b.HeaderByNumber(ctx, number)
+}
+
+// queriedHeader is the JSON form of the fields queried by queryHeaders.
+type queriedHeader struct {
+	Number          hexutil.Uint64 `json:"number"`
+	Lucky           *hexutil.Big   `json:"lucky"`
+	Basis           *hexutil.Big   `json:"basis"`
+	DifficultyAlpha *hexutil.Big   `json:"difficultyAlpha"`
+	DifficultyBeta  *hexutil.Big   `json:"difficultyBeta"`
+	FirstNonce      hexutil.Bytes  `json:"firstNonce"`
+	SecondNonce     hexutil.Bytes  `json:"secondNonce"`
+}
+
+// queryHeaders runs a headers query over the given range against the backend.
+func queryHeaders(t *testing.T, backend fortapi.Backend, args string) ([]queriedHeader, error) {
+	schema, err := graphql.ParseSchema(schema, &Resolver{backend})
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	query := fmt.Sprintf(`{ headers(%s) { number lucky basis difficultyAlpha difficultyBeta firstNonce secondNonce } }`, args)
+	res := schema.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) > 0 {
+		return nil, res.Errors[0]
+	}
+	var data struct {
+		Headers []queriedHeader `json:"headers"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return data.Headers, nil
+}
+
+// checkHeaders verifies that a headers query returned the given block range.
+func checkHeaders(t *testing.T, headers []queriedHeader, from, to uint64) {
+	t.Helper()
+
+	if len(headers) != int(to-from+1) {
+		t.Fatalf("header count mismatch: have %d, want %d", len(headers), to-from+1)
+	}
+	for i, header := range headers {
+		if want := from + uint64(i); uint64(header.Number) != want {
+			t.Errorf("header %d: number mismatch: have %d, want %d", i, header.Number, want)
+		}
+	}
+}
+
+// Tests that the Tppow seal fields of blocks are exposed.
+func TestHeadersTppowFields(t *testing.T) {
+	backend := newHeaderBackend(3)
+
+	headers, err := queryHeaders(t, backend, "from: 2, to: 2")
+	if err != nil {
+		t.Fatalf("failed to query headers: %v", err)
+	}
+	checkHeaders(t, headers, 2, 2)
+
+	have, want := headers[0], backend.headers[2]
+	if have.Lucky.ToInt().Cmp(want.Lucky) != 0 {
+		t.Errorf("lucky mismatch: have %v, want %v", have.Lucky, want.Lucky)
+	}
+	if have.Basis.ToInt().Cmp(want.Basis) != 0 {
+		t.Errorf("basis mismatch: have %v, want %v", have.Basis, want.Basis)
+	}
+	if have.DifficultyAlpha.ToInt().Cmp(want.DifficultyAlpha) != 0 {
+		t.Errorf("alpha mismatch: have %v, want %v", have.DifficultyAlpha, want.DifficultyAlpha)
+	}
+	if have.DifficultyBeta.ToInt().Cmp(want.DifficultyBeta) != 0 {
+		t.Errorf("beta mismatch: have %v, want %v", have.DifficultyBeta, want.DifficultyBeta)
+	}
+	if !bytes.Equal(have.FirstNonce, want.FirstNonce[:]) {
+		t.Errorf("first nonce mismatch: have %x, want %x", have.FirstNonce, want.FirstNonce)
+	}
+	if !bytes.Equal(have.SecondNonce, want.SecondNonce[:]) {
+		t.Errorf("second nonce mismatch: have %x, want %x", have.SecondNonce, want.SecondNonce)
+	}
+}
+
+// Tests that header ranges longer than maxHeaderRange are rejected.
+func TestHeadersRangeLimit(t *testing.T) {
+	backend := newHeaderBackend(maxHeaderRange)
+
+	headers, err := queryHeaders(t, backend, fmt.Sprintf("from: 1, to: %d", maxHeaderRange))
+	if err != nil {
+		t.Fatalf("failed to query maximum range: %v", err)
+	}
+	checkHeaders(t, headers, 1, maxHeaderRange)
+
+	_, err = queryHeaders(t, backend, fmt.Sprintf("from: 0, to: %d", maxHeaderRange))
+	if err == nil || !strings.Contains(err.Error(), errHeaderRange.Error()) {
+		t.Errorf("error mismatch: have %v, want %v", err, errHeaderRange)
+	}
+}
+
+// Tests that header ranges are clamped at the chain head.
+func TestHeadersClampHead(t *testing.T) {
+	backend := newHeaderBackend(5)
+
+	headers, err := queryHeaders(t, backend, "from: 3, to: 100")
+	if err != nil {
+		t.Fatalf("failed to query headers: %v", err)
+	}
+	checkHeaders(t, headers, 3, 5)
+
+	headers, err = queryHeaders(t, backend, "from: 4")
+	if err != nil {
+		t.Fatalf("failed to query headers: %v", err)
+	}
+	checkHeaders(t, headers, 4, 5)
+
+	headers, err = queryHeaders(t, backend, "from: 6")
+	if err != nil {
+		t.Fatalf("failed to query headers: %v", err)
+	}
+	if len(headers) != 0 {
+		t.Errorf("headers past the head returned: %v", headers)
+	}
+}
+
+// Tests that header ranges are truncated at the first missing ancestor.
+func TestHeadersMissingAncestors(t *testing.T) {
+	backend := newHeaderBackend(5)
+	backend.drop(2)
+
+	headers, err := queryHeaders(t, backend, "from: 0, to: 5")
+	if err != nil {
+		t.Fatalf("failed to query headers: %v", err)
+	}
+	checkHeaders(t, headers, 3, 5)
+}
@@ -148,8 +148,14 @@ const schema string = `
         hash: Bytes32!
         # Parent is the parent block of this block.
         parent: Block
-        # Nonce is the block nonce, an 8 byte sequence determined by the miner.
-        nonce: Bytes!
+        # Nonce is the ethash block nonce, always zero on Tppow chains.
+        nonce: Bytes! @deprecated(reason: "Use firstNonce and secondNonce")
+        # FirstNonce is the first stage nonce of the Tppow seal, satisfying
+        # difficultyAlpha and determining the luck of this block.
+        firstNonce: Bytes!
+        # SecondNonce is the second stage nonce of the Tppow seal, satisfying
+        # difficultyBeta.
+        secondNonce: Bytes!
         # TransactionsRoot is the keccak256 hash of the root of the trie of transactions in this block.
         transactionsRoot: Bytes32!
         # TransactionCount is the number of transactions in this block. if
@@ -172,10 +178,19 @@ const schema string = `
         # LogsBloom is a bloom filter that can be used to check if a block may
         # contain log entries matching a filter.
         logsBloom: Bytes!
-        # MixHash is the hash that was used as an input to the PoW process.
-        mixHash: Bytes32!
+        # MixHash is the ethash mix digest, always zero on Tppow chains.
+        mixHash: Bytes32! @deprecated(reason: "Not used by Tppow")
         # Difficulty is a measure of the difficulty of mining this block.
         difficulty: BigInt!
+        # Lucky is the luck derived from the first stage nonce of this block.
+        lucky: BigInt!
+        # Basis is the base second stage target this block was mined against.
+        basis: BigInt!
+        # DifficultyAlpha is the target the first stage seal had to stay below.
+        difficultyAlpha: BigInt!
+        # DifficultyBeta is the target the second stage seal had to stay below,
+        # derived from the basis and the luck of this block.
+        difficultyBeta: BigInt!
         # TotalDifficulty is the sum of all difficulty values up to and including
         # this block.
         totalDifficulty: BigInt!
@@ -301,6 +316,11 @@ const schema string = `
         # Blocks returns all the blocks between two numbers, inclusive. If
         # to is not supplied, it defaults to the most recent known block.
         blocks(from: Long!, to: Long): [Block!]!
+        # Headers returns the blocks between two numbers, inclusive, with their
+        # headers retrieved up front. It is meant for scanning header fields over
+        # long ranges and returns at most 1000 blocks. If to is not supplied, it
+        # defaults to the most recent known block.
+        headers(from: Long!, to: Long): [Block!]!
         # Pending returns the current pending state.
         pending: Pending!
         # Transaction returns a transaction specified by its hash.
@@ -0,0 +1,74 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package fortapi
+
+import (
+	"encoding/json"
+
+	"github.com/luck/go-luck/common"
+)
+
+// strictAddress is an address argument parsed with common.ParseAddress, so that
+// it may be given in bech32 and mixed-case hex input must carry a valid checksum.
+type strictAddress common.Address
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *strictAddress) UnmarshalText(input []byte) error {
+	addr, err := common.ParseAddress(string(input))
+	if err != nil {
+		return err
+	}
+	*a = strictAddress(addr)
+	return nil
+}
+
+// UnmarshalJSON decodes the call arguments, parsing the sender and recipient
+// addresses strictly.
+func (args *CallArgs) UnmarshalJSON(input []byte) error {
+	type callArgs CallArgs
+	dec := struct {
+		*callArgs
+		From *strictAddress `json:"from"`
+		To   *strictAddress `json:"to"`
+	}{callArgs: (*callArgs)(args)}
+
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	args.From, args.To = (*common.Address)(dec.From), (*common.Address)(dec.To)
+	return nil
+}
+
+// UnmarshalJSON decodes the transaction arguments, parsing the sender and
+// recipient addresses strictly.
+func (args *SendTxArgs) UnmarshalJSON(input []byte) error {
+	type sendTxArgs SendTxArgs
+	dec := struct {
+		*sendTxArgs
+		From *strictAddress `json:"from"`
+		To   *strictAddress `json:"to"`
+	}{sendTxArgs: (*sendTxArgs)(args)}
+
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.From != nil {
+		args.From = common.Address(*dec.From)
+	}
+	args.To = (*common.Address)(dec.To)
+	return nil
+}
@@ -31,8 +31,10 @@ var Modules = map[string]string{
 	"rpc":        RpcJs,
 	"shh":        ShhJs,
 	"swarmfs":    SwarmfsJs,
+	"tppow":      TppowJs,
 	"txpool":     TxpoolJs,
 	"les":        LESJs,
+	"luck":       LuckJs,
 	"lespay":     LESPayJs,
 }
 
@@ -146,6 +148,77 @@ web3._extend({
 });
 `
 
+const TppowJs = `
+web3._extend({
+	property: 'tppow',
+	methods: [
+		new web3._extend.Method({
+			name: 'getWork',
+			call: 'tppow_getWork',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'submitLuck',
+			call: 'tppow_submitLuck',
+			params: 2,
+		}),
+		new web3._extend.Method({
+			name: 'submitWork',
+			call: 'tppow_submitWork',
+			params: 3,
+		}),
+		new web3._extend.Method({
+			name: 'submitHashRate',
+			call: 'tppow_submitHashRate',
+			params: 2,
+		}),
+		new web3._extend.Method({
+			name: 'getHashrate',
+			call: 'tppow_getHashrate',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getLuckHashrate',
+			call: 'tppow_getLuckHashrate',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getBlockHashrate',
+			call: 'tppow_getBlockHashrate',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getLuck',
+			call: 'tppow_getLuck',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'verifySeal',
+			call: 'tppow_verifySeal',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'estimateNextParams',
+			call: 'tppow_estimateNextParams',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'calcBeta',
+			call: 'tppow_calcBeta',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'getRangeStats',
+			call: 'tppow_getRangeStats',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
 const AdminJs = `
 web3._extend({
 	property: 'admin',
@@ -181,6 +254,16 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportTxPool',
+			call: 'admin_exportTxPool',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'importTxPool',
+			call: 'admin_importTxPool',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -602,6 +685,14 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Method({
+			name: 'getThreads',
+			call: 'miner_getThreads'
+		}),
+		new web3._extend.Method({
+			name: 'getTemplate',
+			call: 'miner_getTemplate'
+		}),
 	],
 	properties: []
 });
@@ -760,6 +851,19 @@ web3._extend({
 });
 `
 
+const LuckJs = `
+web3._extend({
+	property: 'luck',
+	methods: [
+		new web3._extend.Method({
+			name: 'sendPrivateTransaction',
+			call: 'luck_sendPrivateTransaction',
+			params: 1
+		}),
+	]
+});
+`
+
 const AccountingJs = `
 web3._extend({
 	property: 'accounting',
@@ -175,6 +175,10 @@ func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.fort.txPool.Add(ctx, signedTx)
 }
 
+func (b *LesApiBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return errors.New("private transactions are not supported by light clients")
+}
+
 func (b *LesApiBackend) RemoveTx(txHash common.Hash) {
 	b.fort.txPool.RemoveTx(txHash)
 }
@@ -203,6 +207,18 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.fort.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolQueueReasons() map[common.Address]map[uint64]core.QueueReason {
+	return nil // The light pool does not hold non-executable transactions
+}
+
+func (b *LesApiBackend) TxPoolExport() (types.Transactions, error) {
+	return nil, errors.New("transaction pool export is not supported by light clients")
+}
+
+func (b *LesApiBackend) TxPoolImport(txs types.Transactions) ([]error, error) {
+	return nil, errors.New("transaction pool import is not supported by light clients")
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.fort.txPool.SubscribeNewTxsEvent(ch)
 }
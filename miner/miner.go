@@ -151,6 +151,31 @@ func (miner *Miner) HashRate() uint64 {
 	return 0
 }
 
+// threaded is implemented by consensus engines that seal blocks locally on a
+// configurable number of CPU threads.
+type threaded interface {
+	Threads() int
+	SetThreads(threads int)
+}
+
+// Threads returns the number of sealing threads enabled in the consensus engine,
+// or zero if the engine does not seal on local threads.
+func (miner *Miner) Threads() int {
+	if th, ok := miner.engine.(threaded); ok {
+		return th.Threads()
+	}
+	return 0
+}
+
+// SetThreads updates the number of sealing threads used by the consensus engine.
+// A running seal picks up the new thread count immediately. Zero means all
+// cores of the machine, a negative count disables local sealing altogether.
+func (miner *Miner) SetThreads(threads int) {
+	if th, ok := miner.engine.(threaded); ok {
+		th.SetThreads(threads)
+	}
+}
+
 func (miner *Miner) SetExtra(extra []byte) error {
 	if uint64(len(extra)) > params.MaximumExtraDataSize {
 		return fmt.Errorf("extra exceeds max length. %d > %v", len(extra), params.MaximumExtraDataSize)
@@ -169,6 +194,12 @@ func (miner *Miner) Pending() (*types.Block, *state.StateDB) {
 	return miner.worker.pending()
 }
 
+// PendingTemplate returns the sealing template of the currently pending block,
+// or nil if no block has been prepared yet.
+func (miner *Miner) PendingTemplate() *Template {
+	return miner.worker.pendingTemplate()
+}
+
 // PendingBlock returns the currently pending block.
 //
 // Note, to access both the pending block and the pending state
@@ -188,3 +219,10 @@ func (miner *Miner) SetEtherbase(addr common.Address) {
 func (self *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
 	return self.worker.pendingLogsFeed.Subscribe(ch)
 }
+
+// SubscribeTemplates starts delivering the sealing template of every new block
+// the miner commits to the given channel. Templates are dropped for the channel
+// while it is full, so the miner never waits on subscribers.
+func (miner *Miner) SubscribeTemplates(ch chan<- *Template) event.Subscription {
+	return miner.worker.templateFeed.Subscribe(ch)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/consensus"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/event"
+)
+
+// rewarder is implemented by consensus engines able to report the rewards a
+// block credits without executing it.
+type rewarder interface {
+	BlockRewards(header *types.Header, uncles []*types.Header) (reward *big.Int, author common.Address, authorReward *big.Int)
+}
+
+// Template is a candidate block prepared for sealing, along with the fees and
+// rewards sealing it would earn.
+type Template struct {
+	Header       *types.Header   // Prepared header, including the consensus fields
+	SealHash     common.Hash     // Hash work packages derived from the header are identified by
+	Uncles       []*types.Header // Uncles included in the block
+	Transactions []*TemplateTx   // Transactions included in the block, in order
+	Fees         *big.Int        // Sum of the fees paid by all transactions
+
+	Reward       *big.Int       // Reward of the coinbase, excluding fees (nil if not known to the engine)
+	Author       common.Address // Beneficiary of the author reward
+	AuthorReward *big.Int       // Cut of the author reward address (nil if not known to the engine)
+}
+
+// TemplateTx is a transaction included in a block template.
+type TemplateTx struct {
+	Tx      *types.Transaction
+	GasUsed uint64   // Gas used executing the transaction on the pending state
+	Fee     *big.Int // Fee paid to the coinbase, the gas used times the gas price
+}
+
+// newTemplate assembles the sealing template of a block, the receipts of which
+// were produced while executing it on top of its parent.
+func newTemplate(engine consensus.Engine, block *types.Block, receipts []*types.Receipt) *Template {
+	tmpl := &Template{
+		Header:       block.Header(),
+		SealHash:     engine.SealHash(block.Header()),
+		Uncles:       block.Uncles(),
+		Transactions: make([]*TemplateTx, 0, len(block.Transactions())),
+		Fees:         new(big.Int),
+	}
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tx.GasPrice())
+		tmpl.Transactions = append(tmpl.Transactions, &TemplateTx{Tx: tx, GasUsed: receipts[i].GasUsed, Fee: fee})
+		tmpl.Fees.Add(tmpl.Fees, fee)
+	}
+	if r, ok := engine.(rewarder); ok {
+		tmpl.Reward, tmpl.Author, tmpl.AuthorReward = r.BlockRewards(tmpl.Header, tmpl.Uncles)
+	}
+	return tmpl
+}
+
+// templateFeed delivers block templates to subscribers without ever waiting for
+// them. Templates are posted from the worker's main loop, so a subscriber not
+// keeping up misses templates instead of holding up block production.
+type templateFeed struct {
+	lock sync.Mutex
+	subs map[*templateSub]struct{}
+}
+
+// templateSub is a single subscriber of a template feed.
+type templateSub struct {
+	ch chan<- *Template
+}
+
+// Subscribe adds a channel to the feed. Templates arriving while the channel is
+// full are dropped for it, so it should be buffered.
+func (f *templateFeed) Subscribe(ch chan<- *Template) event.Subscription {
+	sub := &templateSub{ch: ch}
+
+	f.lock.Lock()
+	if f.subs == nil {
+		f.subs = make(map[*templateSub]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	f.lock.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		f.lock.Lock()
+		delete(f.subs, sub)
+		f.lock.Unlock()
+		return nil
+	})
+}
+
+// Len returns the number of subscribers of the feed.
+func (f *templateFeed) Len() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.subs)
+}
+
+// Send delivers a template to all subscribers ready to receive it, returning the
+// number of them that got it.
+func (f *templateFeed) Send(tmpl *Template) (nsent int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for sub := range f.subs {
+		select {
+		case sub.ch <- tmpl:
+			nsent++
+		default:
+			// Subscriber lagging behind, it will catch up with the next template
+		}
+	}
+	return nsent
+}
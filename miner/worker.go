@@ -130,6 +130,7 @@ type worker struct {
 
 	// Feeds
 	pendingLogsFeed event.Feed
+	templateFeed    templateFeed
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -161,9 +162,10 @@ type worker struct {
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
 
-	snapshotMu    sync.RWMutex // The lock used to protect the block snapshot and state snapshot
-	snapshotBlock *types.Block
-	snapshotState *state.StateDB
+	snapshotMu       sync.RWMutex // The lock used to protect the block snapshot and state snapshot
+	snapshotBlock    *types.Block
+	snapshotState    *state.StateDB
+	snapshotReceipts []*types.Receipt
 
 	// atomic status counters
 	running int32 // The indicator whforter the consensus engine is running or not.
@@ -177,6 +179,7 @@ type worker struct {
 	skipSealHook func(*task) bool                   // Method to decide whforter skipping the sealing.
 	fullTaskHook func()                             // Method to call before pushing the full sealing task.
 	resubmitHook func(time.Duration, time.Duration) // Method to call upon updating resubmitting interval.
+	resultHook   func(*types.Block)                 // Method to call upon receiving a sealing result.
 }
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, fort Backend, mux *event.TypeMux, isLocalBlock func(*types.Block) bool, init bool) *worker {
@@ -266,6 +269,16 @@ func (w *worker) pendingBlock() *types.Block {
 	return w.snapshotBlock
 }
 
+// pendingTemplate returns the sealing template of the pending block.
+func (w *worker) pendingTemplate() *Template {
+	w.snapshotMu.RLock()
+	defer w.snapshotMu.RUnlock()
+	if w.snapshotBlock == nil {
+		return nil
+	}
+	return newTemplate(w.engine, w.snapshotBlock, w.snapshotReceipts)
+}
+
 // start sets the running status as 1 and triggers new work submitting.
 func (w *worker) start() {
 	atomic.StoreInt32(&w.running, 1)
@@ -363,6 +376,9 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 					timer.Reset(recommit)
 					continue
 				}
+				// Keep the timestamp of the round, so that engines can carry over sealing
+				// work which doesn't depend on the transactions (e.g. the first stage of
+				// Tppow).
 				commit(true, commitInterruptResubmit)
 			}
 
@@ -538,11 +554,12 @@ func (w *worker) taskLoop() {
 			w.pendingTasks[w.engine.SealHash(task.block.Header())] = task
 			w.pendingMu.Unlock()
 
-			go func() {
+			// Hand the current stop channel over, the loop replaces it on new work
+			go func(stopCh chan struct{}) {
 				if err := w.engine.Seal(w.chain, task.block, w.resultCh, stopCh); err != nil {
 					log.Warn("Block sealing failed", "err", err)
-				} 
-			}()
+				}
+			}(stopCh)
 		case <-w.exitCh:
 			interrupt()
 			return
@@ -560,6 +577,9 @@ func (w *worker) resultLoop() {
 			if block == nil {
 				continue
 			}
+			if w.resultHook != nil {
+				w.resultHook(block)
+			}
 			// Short circuit when receiving duplicate result caused by resubmitting.
 			if w.chain.HasBlock(block.Hash(), block.NumberU64()) {
 				continue
@@ -623,7 +643,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		return err
 	}
 	env := &environment{
-		signer:    types.NewEIP155Signer(w.chainConfig.ChainID),
+		signer:    types.MakeSigner(w.chainConfig, header.Number),
 		state:     state,
 		ancestors: mapset.NewSet(),
 		family:    mapset.NewSet(),
@@ -696,6 +716,7 @@ func (w *worker) updateSnapshot() {
 	)
 
 	w.snapshotState = w.current.state.Copy()
+	w.snapshotReceipts = append([]*types.Receipt(nil), w.current.receipts...)
 }
 
 func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
@@ -1009,6 +1030,9 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	if update {
 		w.updateSnapshot()
 	}
+	if w.templateFeed.Len() > 0 {
+		w.templateFeed.Send(newTemplate(w.engine, block, receipts))
+	}
 	return nil
 }
 
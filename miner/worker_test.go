@@ -19,6 +19,7 @@ package miner
 import (
 	"math/big"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/luck/go-luck/consensus"
 	"github.com/luck/go-luck/consensus/clique"
 	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/consensus/tppow"
 	"github.com/luck/go-luck/core"
 	"github.com/luck/go-luck/core/rawdb"
 	"github.com/luck/go-luck/core/types"
@@ -111,7 +113,7 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 		e.Authorize(testBankAddress, func(account accounts.Account, s string, data []byte) ([]byte, error) {
 			return crypto.Sign(crypto.Keccak256(data), testBankKey)
 		})
-	case *ethash.Ethash:
+	case *ethash.Ethash, *tppow.Tppow:
 	default:
 		t.Fatalf("unexpected consensus engine type: %T", engine)
 	}
@@ -503,3 +505,204 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestPendingTemplateEthash(t *testing.T) {
+	testPendingTemplate(t, ethash.NewFaker(), func(w *worker, tmpl *Template) {
+		if tmpl.Reward != nil || tmpl.AuthorReward != nil {
+			t.Errorf("rewards reported by engine without rewarder")
+		}
+	})
+}
+
+func TestPendingTemplateTppow(t *testing.T) {
+	engine := tppow.NewFaker()
+	testPendingTemplate(t, engine, func(w *worker, tmpl *Template) {
+		// The template is taken before sealing, with only the first stage target
+		header := tmpl.Header
+		if header.Lucky == nil || header.Lucky.Sign() != 0 {
+			t.Errorf("unsealed luck mismatch: have %v, want 0", header.Lucky)
+		}
+		if header.DifficultyBeta == nil || header.DifficultyBeta.Sign() != 0 {
+			t.Errorf("unsealed beta mismatch: have %v, want 0", header.DifficultyBeta)
+		}
+		prepared := &types.Header{ParentHash: header.ParentHash, Number: header.Number, Time: header.Time}
+		if err := engine.Prepare(w.chain, prepared); err != nil {
+			t.Fatalf("failed to prepare header: %v", err)
+		}
+		if header.DifficultyAlpha.Sign() <= 0 || header.DifficultyAlpha.Cmp(prepared.DifficultyAlpha) != 0 {
+			t.Errorf("alpha mismatch: have %v, want %v", header.DifficultyAlpha, prepared.DifficultyAlpha)
+		}
+		if header.Basis.Cmp(prepared.Basis) != 0 {
+			t.Errorf("basis mismatch: have %v, want %v", header.Basis, prepared.Basis)
+		}
+		// The rewards are the ones the engine credits when finalizing the block
+		reward, author, authorReward := engine.BlockRewards(header, tmpl.Uncles)
+		if tmpl.Reward == nil || tmpl.Reward.Sign() <= 0 || tmpl.Reward.Cmp(reward) != 0 {
+			t.Errorf("reward mismatch: have %v, want %v", tmpl.Reward, reward)
+		}
+		if tmpl.Author != author || tmpl.Author != params.AuthorRewardAddr {
+			t.Errorf("author mismatch: have %x, want %x", tmpl.Author, author)
+		}
+		if tmpl.AuthorReward == nil || tmpl.AuthorReward.Cmp(authorReward) != 0 {
+			t.Errorf("author reward mismatch: have %v, want %v", tmpl.AuthorReward, authorReward)
+		}
+		// Sealing fills in the luck and the second stage target derived from
+		// it, which for the zero luck of the faker is the basis itself
+		var block *types.Block
+		for i := 0; i < 30 && block == nil; i++ {
+			if block = w.chain.GetBlockByNumber(1); block == nil {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+		if block == nil {
+			t.Fatalf("sealed block not imported")
+		}
+		if sealed := block.Header(); sealed.Lucky.Sign() != 0 || sealed.DifficultyBeta.Cmp(sealed.Basis) != 0 {
+			t.Errorf("sealed beta mismatch: have %v, want %v", sealed.DifficultyBeta, sealed.Basis)
+		}
+	})
+}
+
+// testPendingTemplate checks the engine independent parts of the first block
+// template the worker publishes, leaving the rest to the engine specific check.
+func testPendingTemplate(t *testing.T, engine consensus.Engine, check func(*worker, *Template)) {
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	templates := make(chan *Template, 1)
+	sub := w.templateFeed.Subscribe(templates)
+	defer sub.Unsubscribe()
+
+	w.start()
+	select {
+	case tmpl := <-templates:
+		if tmpl.Header.Coinbase != testBankAddress {
+			t.Errorf("coinbase mismatch: have %x, want %x", tmpl.Header.Coinbase, testBankAddress)
+		}
+		if len(tmpl.Transactions) != len(pendingTxs) {
+			t.Fatalf("transaction count mismatch: have %d, want %d", len(tmpl.Transactions), len(pendingTxs))
+		}
+		if tx := tmpl.Transactions[0]; tx.Tx.Hash() != pendingTxs[0].Hash() || tx.GasUsed != params.TxGas {
+			t.Errorf("transaction mismatch: have %x/%d, want %x/%d", tx.Tx.Hash(), tx.GasUsed, pendingTxs[0].Hash(), params.TxGas)
+		}
+		if tmpl.SealHash != w.engine.SealHash(tmpl.Header) {
+			t.Errorf("seal hash mismatch: have %x, want %x", tmpl.SealHash, w.engine.SealHash(tmpl.Header))
+		}
+		check(w, tmpl)
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout")
+	}
+	// Sealed blocks get imported, so the pending block may already have moved on
+	if tmpl := w.pendingTemplate(); tmpl == nil || tmpl.Header.Number.Uint64() < 1 {
+		t.Errorf("pending template mismatch: have %v, want block 1 or later", tmpl)
+	}
+}
+
+// Tests that templates are only delivered to subscribers ready to receive them,
+// never blocking the worker on a lagging one.
+func TestTemplateFeedLagging(t *testing.T) {
+	var feed templateFeed
+
+	lagging, ready := make(chan *Template), make(chan *Template, 1)
+	sub1, sub2 := feed.Subscribe(lagging), feed.Subscribe(ready)
+	if n := feed.Len(); n != 2 {
+		t.Fatalf("subscriber count mismatch: have %d, want %d", n, 2)
+	}
+	tmpl := &Template{Header: &types.Header{Number: big.NewInt(1)}}
+	if n := feed.Send(tmpl); n != 1 {
+		t.Errorf("delivery count mismatch: have %d, want %d", n, 1)
+	}
+	if have := <-ready; have != tmpl {
+		t.Errorf("delivered template mismatch: have %v, want %v", have, tmpl)
+	}
+	sub1.Unsubscribe()
+	sub2.Unsubscribe()
+	if n := feed.Len(); n != 0 {
+		t.Errorf("subscriber count mismatch after unsubscribe: have %d, want %d", n, 0)
+	}
+}
+
+// Tests that blocks sealed while the result loop is busy are not silently lost
+// when new work gets committed in the meantime: every one of them is either
+// delivered or explicitly reported as superseded, and the latest one is always
+// delivered.
+func TestSealResultRace(t *testing.T) {
+	engine := tppow.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		lock      sync.Mutex
+		delivered = make(map[common.Hash]bool)
+		release   = make(chan struct{})
+	)
+	w.resultHook = func(block *types.Block) {
+		<-release // Hold up the result loop until all the work is committed
+
+		lock.Lock()
+		defer lock.Unlock()
+		delivered[engine.SealHash(block.Header())] = true
+	}
+	discards := make(chan tppow.SealDiscardedEvent, 4*resultQueueSize)
+	sub := engine.SubscribeSealDiscarded(discards)
+	defer sub.Unsubscribe()
+
+	// Assemble a block to seal on top of the genesis and commit a series of new
+	// work with it, differing in extra data only for none to be deduplicated.
+	w.commitNewWork(nil, false, time.Now().Unix())
+	block, err := engine.FinalizeAndAssemble(w.chain, w.current.header, w.current.state.Copy(), w.current.txs, nil, w.current.receipts)
+	if err != nil {
+		t.Fatalf("failed to assemble block: %v", err)
+	}
+	var sealed []common.Hash
+	for i := 0; i < 2*resultQueueSize+1; i++ {
+		header := block.Header()
+		header.Extra = []byte{byte(i)}
+
+		task := &task{receipts: w.current.receipts, state: w.current.state.Copy(), block: block.WithSeal(header), createdAt: time.Now()}
+		w.taskCh <- task
+		sealed = append(sealed, engine.SealHash(header))
+	}
+	// Let the result loop catch up and ensure every seal is accounted for
+	close(release)
+
+	var (
+		superseded = make(map[common.Hash]bool)
+		timeout    = time.After(3 * time.Second)
+	)
+	for {
+		lock.Lock()
+		missing := 0
+		for _, hash := range sealed {
+			if !delivered[hash] && !superseded[hash] {
+				missing++
+			}
+		}
+		lock.Unlock()
+		if missing == 0 {
+			break
+		}
+		select {
+		case ev := <-discards:
+			superseded[ev.SealHash] = true
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("%d sealed blocks neither delivered nor superseded", missing)
+		}
+	}
+	if len(superseded) == 0 {
+		t.Errorf("no seals superseded while the result loop was busy")
+	}
+	for hash := range superseded {
+		if delivered[hash] {
+			t.Errorf("seal %x both delivered and superseded", hash)
+		}
+	}
+	if last := sealed[len(sealed)-1]; !delivered[last] {
+		t.Errorf("latest seal %x not delivered", last)
+	}
+}
@@ -186,7 +186,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 				var lesServ *les.LightLuck
 				ctx.Service(&lesServ)
 
-				return fortstats.New(config.LuckNetStats, nil, lesServ)
+				return fortstats.New(config.LuckNetStats, false, nil, lesServ)
 			}); err != nil {
 				return nil, fmt.Errorf("netstats init: %v", err)
 			}
@@ -162,6 +162,11 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCStrictAddresses rejects RPC calls on all endpoints whose arguments hold
+	// addresses that look like zero-padded 20 byte Ethereum addresses, guarding
+	// users against clients that silently pad short input to full size.
+	RPCStrictAddresses bool `toml:",omitempty"`
+
 	// GraphQLHost is the host interface on which to start the GraphQL server. If this
 	// field is empty, no GraphQL API endpoint will be started.
 	GraphQLHost string `toml:",omitempty"`
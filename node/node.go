@@ -315,6 +315,7 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetStrictAddresses(n.config.RPCStrictAddresses)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return err
@@ -342,6 +343,7 @@ func (n *Node) startIPC(apis []rpc.API) error {
 	if err != nil {
 		return err
 	}
+	handler.SetStrictAddresses(n.config.RPCStrictAddresses)
 	n.ipcListener = listener
 	n.ipcHandler = handler
 	n.log.Info("IPC endpoint opened", "url", n.ipcEndpoint)
@@ -370,6 +372,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// register apis and create handler stack
 	srv := rpc.NewServer()
+	srv.SetStrictAddresses(n.config.RPCStrictAddresses)
 	err := RegisterApisFromWhitelist(apis, modules, srv, false)
 	if err != nil {
 		return err
@@ -419,6 +422,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 
 	srv := rpc.NewServer()
+	srv.SetStrictAddresses(n.config.RPCStrictAddresses)
 	handler := srv.WebsocketHandler(wsOrigins)
 	err := RegisterApisFromWhitelist(apis, modules, srv, exposeAll)
 	if err != nil {
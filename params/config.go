@@ -53,6 +53,23 @@ var CheckpointOracles = map[common.Hash]*CheckpointOracleConfig{
 
 var AuthorRewardAddr = common.HexToAddress("0xbfd1432766fba68e1d4c04286f4a3295c70ae9092af5f052ac")
 
+// DefaultTppowConfig contains the Tppow consensus parameters of the main network.
+// Any field left unset in a chain's TppowConfig falls back to these values.
+var DefaultTppowConfig = &TppowConfig{
+	BlockReward:           big.NewInt(1e+18),
+	HalvingInterval:       3110400, // 2 years = 2 * 360 * 24 * 60 * 3
+	TargetBlockTime:       20,
+	MaxLuck:               big.NewInt(2e+8),
+	HashScale:             big.NewInt(4e+18),
+	DifficultyAdjustBlock: big.NewInt(39200),
+	AuthorRewardPercent:   newUint64(5),
+	AuthorRewardAddr:      AuthorRewardAddr,
+	V2RetargetWindow:      60,
+	V2Argon2Memory:        64 * 1024,
+	UncleRewardDivisor:    8,
+	NephewRewardDivisor:   32,
+}
+
 var (
 	// MainnetChainConfig is the chain parameters to run a node on the main network.
 	MainnetChainConfig = &ChainConfig{
@@ -70,6 +87,7 @@ var (
 		IstanbulBlock:       big.NewInt(0),
 		MuirGlacierBlock:    big.NewInt(0),
 		Ethash:              new(EthashConfig),
+		Tppow:               DefaultTppowConfig,
 	}
 
 	// MainnetTrustedCheckpoint contains the light client trusted checkpoint for the main network.
@@ -148,6 +166,7 @@ var (
 		IstanbulBlock:       big.NewInt(0),
 		MuirGlacierBlock:    big.NewInt(0),
 		Ethash:              new(EthashConfig),
+		Tppow:               DefaultTppowConfig,
 	}
 
 	// TestnetTrustedCheckpoint contains the light client trusted checkpoint for the Testnet test network.
@@ -215,16 +234,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Luck core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -295,11 +314,13 @@ type ChainConfig struct {
 	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`     // Petersburg switch block (nil = same as Constantinople)
 	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`       // Istanbul switch block (nil = no fork, 0 = already on istanbul)
 	MuirGlacierBlock    *big.Int `json:"muirGlacierBlock,omitempty"`    // Eip-2384 (bomb delay) switch block (nil = no fork, 0 = already activated)
+	BerlinBlock         *big.Int `json:"berlinBlock,omitempty"`         // Berlin switch block (nil = no fork, 0 = already on berlin)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+	Tppow  *TppowConfig  `json:"tppow,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -321,10 +342,124 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// TppowConfig is the consensus engine configs for two-stage proof-of-work based
+// sealing. Unset fields fall back to the values in DefaultTppowConfig.
+type TppowConfig struct {
+	BlockReward           *big.Int       `json:"blockReward,omitempty"`           // Block reward in wei before any reduction
+	HalvingInterval       uint64         `json:"halvingInterval,omitempty"`       // Number of blocks after which the block reward is cut by 10%
+	TargetBlockTime       uint64         `json:"targetBlockTime,omitempty"`       // Number of seconds between blocks the difficulty retargets towards
+	MaxLuck               *big.Int       `json:"maxLuck,omitempty"`               // Exclusive upper bound of the luck derived from the first nonce
+	HashScale             *big.Int       `json:"hashScale,omitempty"`             // Divisor applied to the Argon2 hashes of both seal stages
+	DifficultyAdjustBlock *big.Int       `json:"difficultyAdjustBlock,omitempty"` // Block from which the difficulty is derived from the basis instead of the luck
+	AuthorRewardPercent   *uint64        `json:"authorRewardPercent,omitempty"`   // Percentage of the block reward additionally minted to the author
+	AuthorRewardAddr      common.Address `json:"authorRewardAddr,omitempty"`      // Recipient of the author reward
+
+	V2Block          *big.Int `json:"v2Block,omitempty"`          // TppowV2 switch block (nil = no fork, 0 = already activated)
+	V2RetargetWindow uint64   `json:"v2RetargetWindow,omitempty"` // Number of blocks the TppowV2 weighted retarget averages over
+	V2Argon2Memory   uint32   `json:"v2Argon2Memory,omitempty"`   // Argon2 memory in KiB used by both seal stages since TppowV2
+	V2BlockReward    *big.Int `json:"v2BlockReward,omitempty"`    // Block reward in wei before any reduction since TppowV2 (nil = unchanged)
+
+	UncleBlock          *big.Int `json:"uncleBlock,omitempty"`          // Uncle inclusion and rewards switch block (nil = no fork, 0 = already activated)
+	UncleRewardDivisor  uint64   `json:"uncleRewardDivisor,omitempty"`  // An uncle of depth d earns (divisor - d) / divisor of the block reward
+	NephewRewardDivisor uint64   `json:"nephewRewardDivisor,omitempty"` // The including block earns 1 / divisor of the block reward per uncle
+
+	PrecompileBlock *big.Int `json:"precompileBlock,omitempty"` // Argon2 and seal verification precompiles switch block (nil = no fork, 0 = already activated)
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *TppowConfig) String() string {
+	return "tppow"
+}
+
+// WithDefaults returns a copy of the config with all unset fields filled in
+// from DefaultTppowConfig. A nil config yields the defaults.
+func (c *TppowConfig) WithDefaults() *TppowConfig {
+	cpy := *DefaultTppowConfig
+	if c == nil {
+		return &cpy
+	}
+	if c.BlockReward != nil {
+		cpy.BlockReward = c.BlockReward
+	}
+	if c.HalvingInterval != 0 {
+		cpy.HalvingInterval = c.HalvingInterval
+	}
+	if c.TargetBlockTime != 0 {
+		cpy.TargetBlockTime = c.TargetBlockTime
+	}
+	if c.MaxLuck != nil {
+		cpy.MaxLuck = c.MaxLuck
+	}
+	if c.HashScale != nil {
+		cpy.HashScale = c.HashScale
+	}
+	if c.DifficultyAdjustBlock != nil {
+		cpy.DifficultyAdjustBlock = c.DifficultyAdjustBlock
+	}
+	if c.AuthorRewardPercent != nil {
+		cpy.AuthorRewardPercent = c.AuthorRewardPercent
+	}
+	if c.AuthorRewardAddr != (common.Address{}) {
+		cpy.AuthorRewardAddr = c.AuthorRewardAddr
+	}
+	cpy.V2Block = c.V2Block
+	if c.V2RetargetWindow != 0 {
+		cpy.V2RetargetWindow = c.V2RetargetWindow
+	}
+	if c.V2Argon2Memory != 0 {
+		cpy.V2Argon2Memory = c.V2Argon2Memory
+	}
+	if c.V2BlockReward != nil {
+		cpy.V2BlockReward = c.V2BlockReward
+	}
+	cpy.UncleBlock = c.UncleBlock
+	if c.UncleRewardDivisor != 0 {
+		cpy.UncleRewardDivisor = c.UncleRewardDivisor
+	}
+	if c.NephewRewardDivisor != 0 {
+		cpy.NephewRewardDivisor = c.NephewRewardDivisor
+	}
+	cpy.PrecompileBlock = c.PrecompileBlock
+	return &cpy
+}
+
+// IsV2 returns whether num is either equal to the TppowV2 fork block or greater.
+func (c *TppowConfig) IsV2(num *big.Int) bool {
+	return c != nil && isForked(c.V2Block, num)
+}
+
+// IsUncles returns whether num is either equal to the uncle policy switch block
+// or greater.
+func (c *TppowConfig) IsUncles(num *big.Int) bool {
+	return c != nil && isForked(c.UncleBlock, num)
+}
+
+// Argon2Memory returns the Argon2 memory size in KiB each seal hash at the given
+// height fills. It expects the config to have its defaults filled in.
+func (c *TppowConfig) Argon2Memory(num *big.Int) uint32 {
+	if c.IsV2(num) {
+		return c.V2Argon2Memory
+	}
+	return crypto.Argon2Memory
+}
+
+// IsPrecompiles returns whether num is either equal to the Argon2 and seal
+// verification precompiles switch block or greater.
+func (c *TppowConfig) IsPrecompiles(num *big.Int) bool {
+	return c != nil && isForked(c.PrecompileBlock, num)
+}
+
+// newUint64 returns a pointer to the given value, used for optional fields.
+func newUint64(v uint64) *uint64 {
+	return &v
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
 	switch {
+	case c.Tppow != nil:
+		engine = c.Tppow
 	case c.Ethash != nil:
 		engine = c.Ethash
 	case c.Clique != nil:
@@ -332,7 +467,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Berlin: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -345,6 +480,7 @@ func (c *ChainConfig) String() string {
 		c.PetersburgBlock,
 		c.IstanbulBlock,
 		c.MuirGlacierBlock,
+		c.BerlinBlock,
 		engine,
 	)
 }
@@ -401,6 +537,22 @@ func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
 	return isForked(c.IstanbulBlock, num)
 }
 
+// IsBerlin returns whether num is either equal to the Berlin fork block or greater.
+func (c *ChainConfig) IsBerlin(num *big.Int) bool {
+	return isForked(c.BerlinBlock, num)
+}
+
+// IsTppowV2 returns whether num is either equal to the TppowV2 fork block or greater.
+func (c *ChainConfig) IsTppowV2(num *big.Int) bool {
+	return c.Tppow.IsV2(num)
+}
+
+// IsTppowPrecompiles returns whether num is either equal to the Tppow precompiles
+// switch block or greater.
+func (c *ChainConfig) IsTppowPrecompiles(num *big.Int) bool {
+	return c.Tppow.IsPrecompiles(num)
+}
+
 // IsEWASM returns whforter num represents a block number after the EWASM fork
 func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
@@ -428,20 +580,22 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *Confi
 // to guarantee that forks can be implemented in a different order than on official networks
 func (c *ChainConfig) CheckConfigForkOrder() error {
 	type fork struct {
-		name  string
-		block *big.Int
+		name     string
+		block    *big.Int
+		optional bool // if true, the fork may be nil and next fork is still allowed
 	}
 	var lastFork fork
 	for _, cur := range []fork{
-		{"homesteadBlock", c.HomesteadBlock},
-		{"eip150Block", c.EIP150Block},
-		{"eip155Block", c.EIP155Block},
-		{"eip158Block", c.EIP158Block},
-		{"byzantiumBlock", c.ByzantiumBlock},
-		{"constantinopleBlock", c.ConstantinopleBlock},
-		{"petersburgBlock", c.PetersburgBlock},
-		{"istanbulBlock", c.IstanbulBlock},
-		{"muirGlacierBlock", c.MuirGlacierBlock},
+		{name: "homesteadBlock", block: c.HomesteadBlock},
+		{name: "eip150Block", block: c.EIP150Block},
+		{name: "eip155Block", block: c.EIP155Block},
+		{name: "eip158Block", block: c.EIP158Block},
+		{name: "byzantiumBlock", block: c.ByzantiumBlock},
+		{name: "constantinopleBlock", block: c.ConstantinopleBlock},
+		{name: "petersburgBlock", block: c.PetersburgBlock},
+		{name: "istanbulBlock", block: c.IstanbulBlock},
+		{name: "muirGlacierBlock", block: c.MuirGlacierBlock, optional: true},
+		{name: "berlinBlock", block: c.BerlinBlock},
 	} {
 		if lastFork.name != "" {
 			// Next one must be higher number
@@ -456,7 +610,10 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 				}
 			}
 		}
-		lastFork = cur
+		// If it was optional and not set, then ignore it
+		if !cur.optional || cur.block != nil {
+			lastFork = cur
+		}
 	}
 	return nil
 }
@@ -498,9 +655,98 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.MuirGlacierBlock, newcfg.MuirGlacierBlock, head) {
 		return newCompatError("Muir Glacier fork block", c.MuirGlacierBlock, newcfg.MuirGlacierBlock)
 	}
+	if isForkIncompatible(c.BerlinBlock, newcfg.BerlinBlock, head) {
+		return newCompatError("Berlin fork block", c.BerlinBlock, newcfg.BerlinBlock)
+	}
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if err := c.Tppow.WithDefaults().checkCompatible(newcfg.Tppow.WithDefaults(), head); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkCompatible checks whether the Tppow parameters can be changed without
+// invalidating already imported blocks.
+func (c *TppowConfig) checkCompatible(newcfg *TppowConfig, head *big.Int) *ConfigCompatError {
+	if err := c.checkBaseCompatible(newcfg, head); err != nil {
+		return err
+	}
+	if err := c.checkV2Compatible(newcfg, head); err != nil {
+		return err
+	}
+	if err := c.checkUnclesCompatible(newcfg, head); err != nil {
+		return err
+	}
+	if isForkIncompatible(c.PrecompileBlock, newcfg.PrecompileBlock, head) {
+		return newCompatError("Tppow precompile block", c.PrecompileBlock, newcfg.PrecompileBlock)
+	}
+	return nil
+}
+
+// checkBaseCompatible checks the parameters of the original ruleset. Apart from
+// the difficulty adjustment block, all of them are in effect since the first block.
+func (c *TppowConfig) checkBaseCompatible(newcfg *TppowConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.DifficultyAdjustBlock, newcfg.DifficultyAdjustBlock, head) {
+		return newCompatError("Tppow difficulty adjustment block", c.DifficultyAdjustBlock, newcfg.DifficultyAdjustBlock)
+	}
+	if !isForked(common.Big1, head) {
+		return nil
+	}
+	switch {
+	case !configNumEqual(c.BlockReward, newcfg.BlockReward):
+		return newCompatError("Tppow block reward", common.Big1, common.Big1)
+	case c.HalvingInterval != newcfg.HalvingInterval:
+		return newCompatError("Tppow halving interval", common.Big1, common.Big1)
+	case c.TargetBlockTime != newcfg.TargetBlockTime:
+		return newCompatError("Tppow target block time", common.Big1, common.Big1)
+	case !configNumEqual(c.MaxLuck, newcfg.MaxLuck):
+		return newCompatError("Tppow max luck", common.Big1, common.Big1)
+	case !configNumEqual(c.HashScale, newcfg.HashScale):
+		return newCompatError("Tppow hash scale", common.Big1, common.Big1)
+	case *c.AuthorRewardPercent != *newcfg.AuthorRewardPercent:
+		return newCompatError("Tppow author reward percent", common.Big1, common.Big1)
+	case c.AuthorRewardAddr != newcfg.AuthorRewardAddr:
+		return newCompatError("Tppow author reward address", common.Big1, common.Big1)
+	}
+	return nil
+}
+
+// checkV2Compatible checks the TppowV2 fork block and the parameters it activates.
+func (c *TppowConfig) checkV2Compatible(newcfg *TppowConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.V2Block, newcfg.V2Block, head) {
+		return newCompatError("TppowV2 fork block", c.V2Block, newcfg.V2Block)
+	}
+	if !c.IsV2(head) {
+		return nil
+	}
+	switch {
+	case c.V2RetargetWindow != newcfg.V2RetargetWindow:
+		return newCompatError("TppowV2 retarget window", c.V2Block, newcfg.V2Block)
+	case c.V2Argon2Memory != newcfg.V2Argon2Memory:
+		return newCompatError("TppowV2 Argon2 memory", c.V2Block, newcfg.V2Block)
+	case !configNumEqual(c.V2BlockReward, newcfg.V2BlockReward):
+		return newCompatError("TppowV2 block reward", c.V2Block, newcfg.V2Block)
+	}
+	return nil
+}
+
+// checkUnclesCompatible checks whether the uncle policy can be changed without
+// invalidating already imported blocks.
+func (c *TppowConfig) checkUnclesCompatible(newcfg *TppowConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.UncleBlock, newcfg.UncleBlock, head) {
+		return newCompatError("Tppow uncle block", c.UncleBlock, newcfg.UncleBlock)
+	}
+	if !c.IsUncles(head) {
+		return nil
+	}
+	switch {
+	case c.UncleRewardDivisor != newcfg.UncleRewardDivisor:
+		return newCompatError("Tppow uncle reward divisor", c.UncleBlock, newcfg.UncleBlock)
+	case c.NephewRewardDivisor != newcfg.NephewRewardDivisor:
+		return newCompatError("Tppow nephew reward divisor", c.UncleBlock, newcfg.UncleBlock)
+	}
 	return nil
 }
 
@@ -568,6 +814,7 @@ type Rules struct {
 	ChainID                                                 *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
+	IsBerlin, IsTppowPrecompiles                            bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -586,5 +833,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsConstantinople: c.IsConstantinople(num),
 		IsPetersburg:     c.IsPetersburg(num),
 		IsIstanbul:       c.IsIstanbul(num),
+		IsBerlin:         c.IsBerlin(num),
+
+		IsTppowPrecompiles: c.IsTppowPrecompiles(num),
 	}
 }
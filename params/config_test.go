@@ -70,6 +70,68 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{Tppow: DefaultTppowConfig},
+			head:    100,
+			wantErr: nil,
+		},
+		{
+			stored:  &ChainConfig{Tppow: DefaultTppowConfig},
+			new:     &ChainConfig{Tppow: &TppowConfig{DifficultyAdjustBlock: big.NewInt(200)}},
+			head:    100,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Tppow: DefaultTppowConfig},
+			new:    &ChainConfig{Tppow: &TppowConfig{DifficultyAdjustBlock: big.NewInt(50)}},
+			head:   100,
+			wantErr: &ConfigCompatError{
+				What:         "Tppow difficulty adjustment block",
+				StoredConfig: big.NewInt(39200),
+				NewConfig:    big.NewInt(50),
+				RewindTo:     49,
+			},
+		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{Tppow: &TppowConfig{TargetBlockTime: 10}},
+			head:    0,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{Tppow: &TppowConfig{TargetBlockTime: 10}},
+			head:   1,
+			wantErr: &ConfigCompatError{
+				What:         "Tppow target block time",
+				StoredConfig: big.NewInt(1),
+				NewConfig:    big.NewInt(1),
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: &ChainConfig{Tppow: &TppowConfig{V2Block: big.NewInt(100)}},
+			new:    &ChainConfig{Tppow: &TppowConfig{V2Block: big.NewInt(200)}},
+			head:   150,
+			wantErr: &ConfigCompatError{
+				What:         "TppowV2 fork block",
+				StoredConfig: big.NewInt(100),
+				NewConfig:    big.NewInt(200),
+				RewindTo:     99,
+			},
+		},
+		{
+			stored: &ChainConfig{Tppow: &TppowConfig{V2Block: big.NewInt(100)}},
+			new:    &ChainConfig{Tppow: &TppowConfig{V2Block: big.NewInt(100), V2Argon2Memory: 1024}},
+			head:   150,
+			wantErr: &ConfigCompatError{
+				What:         "TppowV2 Argon2 memory",
+				StoredConfig: big.NewInt(100),
+				NewConfig:    big.NewInt(100),
+				RewindTo:     99,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +141,20 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestTppowConfigDefaults(t *testing.T) {
+	var nilcfg *TppowConfig
+	if cfg := nilcfg.WithDefaults(); !reflect.DeepEqual(cfg, DefaultTppowConfig) {
+		t.Errorf("nil config defaults mismatch: have %+v, want %+v", cfg, DefaultTppowConfig)
+	}
+	cfg := (&TppowConfig{BlockReward: big.NewInt(7), AuthorRewardPercent: newUint64(0)}).WithDefaults()
+	if cfg.BlockReward.Int64() != 7 {
+		t.Errorf("block reward mismatch: have %v, want %v", cfg.BlockReward, 7)
+	}
+	if *cfg.AuthorRewardPercent != 0 {
+		t.Errorf("author reward percent mismatch: have %v, want %v", *cfg.AuthorRewardPercent, 0)
+	}
+	if cfg.HalvingInterval != DefaultTppowConfig.HalvingInterval {
+		t.Errorf("halving interval mismatch: have %v, want %v", cfg.HalvingInterval, DefaultTppowConfig.HalvingInterval)
+	}
+}
@@ -61,6 +61,13 @@ const (
 	SstoreCleanRefundEIP2200 uint64 = 4200  // Once per SSTORE operation for resetting to the original non-zero value
 	SstoreClearRefundEIP2200 uint64 = 15000 // Once per SSTORE operation for clearing an originally existing storage slot
 
+	ColdAccountAccessCostEIP2929 uint64 = 2600 // Cost of accessing an account not yet in the access list (part of Berlin)
+	ColdSloadCostEIP2929         uint64 = 2100 // Cost of loading a storage slot not yet in the access list (part of Berlin)
+	WarmStorageReadCostEIP2929   uint64 = 100  // Cost of reading an account or slot already in the access list (part of Berlin)
+
+	TxAccessListAddressGas    uint64 = 2400 // Per address specified in an EIP 2930 access list
+	TxAccessListStorageKeyGas uint64 = 1900 // Per storage key specified in an EIP 2930 access list
+
 	JumpdestGas   uint64 = 1     // Once per JUMPDEST operation.
 	EpochDuration uint64 = 30000 // Duration between proof-of-work epochs.
 
@@ -130,6 +137,12 @@ const (
 	Bn256PairingBaseGasIstanbul      uint64 = 45000  // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGasByzantium uint64 = 80000  // Byzantium per-point price for an elliptic curve pairing check
 	Bn256PairingPerPointGasIstanbul  uint64 = 34000  // Per-point price for an elliptic curve pairing check
+
+	Argon2BaseGas    uint64 = 3000      // Base price for an Argon2id hash
+	Argon2PerWordGas uint64 = 12        // Per-word price of the data and salt of an Argon2id hash
+	Argon2PerKiBGas  uint64 = 5         // Per-KiB price of the memory filled by an Argon2id hash (~120ms per 64MiB hash in bench-pow, so a seal check costs ~1M gas)
+	Argon2MaxMemory  uint64 = 64 * 1024 // Maximum memory in KiB a single Argon2id hash may fill, the size of the consensus seal hashes
+	TppowSealGas     uint64 = 10000     // Base price for verifying a Tppow header seal, on top of its three Argon2id hashes
 )
 
 var (
@@ -0,0 +1,124 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/luck/go-luck/common"
+)
+
+const (
+	// legacyAddressLength is the size of the Ethereum style addresses clients
+	// mistakenly send, zero-padded, in place of Luck ones.
+	legacyAddressLength = 20
+
+	// maxArgumentDepth limits how deep call arguments are searched for addresses.
+	maxArgumentDepth = 8
+)
+
+var addressType = reflect.TypeOf(common.Address{})
+
+// invalidAddressError is returned in strict address mode when a call argument
+// holds an address that looks like a zero-padded 20 byte legacy address.
+type invalidAddressError struct{ addr common.Address }
+
+func (e *invalidAddressError) ErrorCode() int { return -32602 }
+
+func (e *invalidAddressError) Error() string {
+	return fmt.Sprintf("invalid address %s: looks like a zero-padded %d byte address, want %d bytes",
+		e.addr.Hex(), legacyAddressLength, common.AddressLength)
+}
+
+// isLegacyAddress reports whether an address looks like a 20 byte address which
+// got left-padded to full size. Addresses whose leading bytes are all zero well
+// into the 20 byte tail, such as precompiles or the zero address, are considered
+// deliberate and are not matched.
+func isLegacyAddress(addr common.Address) bool {
+	pad := common.AddressLength - legacyAddressLength
+	for _, b := range addr[:pad] {
+		if b != 0 {
+			return false
+		}
+	}
+	for _, b := range addr[pad : pad+4] {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAddresses searches the decoded arguments of a call for addresses that
+// look like zero-padded legacy ones. Note addresses of the wrong size are
+// already rejected while decoding the arguments.
+func checkAddresses(args []reflect.Value) error {
+	for _, arg := range args {
+		if err := checkAddressValue(arg, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAddressValue(v reflect.Value, depth int) error {
+	if depth > maxArgumentDepth || !v.IsValid() {
+		return nil
+	}
+	if v.Type() == addressType {
+		if addr := v.Interface().(common.Address); isLegacyAddress(addr) {
+			return &invalidAddressError{addr}
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkAddressValue(v.Elem(), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue // unexported field
+			}
+			if err := checkAddressValue(v.Field(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil // byte blobs, hashes
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkAddressValue(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkAddressValue(iter.Key(), depth+1); err != nil {
+				return err
+			}
+			if err := checkAddressValue(iter.Value(), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"testing"
+
+	"github.com/luck/go-luck/common"
+)
+
+type addressService struct{}
+
+type addressArgs struct {
+	From common.Address
+	To   *common.Address
+}
+
+func (s *addressService) Echo(addr common.Address) common.Address { return addr }
+
+func (s *addressService) Send(args addressArgs) common.Address { return *args.To }
+
+func (s *addressService) Batch(addrs []common.Address) int { return len(addrs) }
+
+func TestStrictAddresses(t *testing.T) {
+	var (
+		legacy  = common.HexToAddress("0x7df9a875a174b3bc565e6424a0050ebc1b2d1d82")
+		address = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314151617")
+		precomp = common.HexToAddress("0x09")
+	)
+	tests := []struct {
+		method string
+		arg    interface{}
+		legacy bool
+	}{
+		{"addr_echo", address, false},
+		{"addr_echo", precomp, false},
+		{"addr_echo", common.Address{}, false},
+		{"addr_echo", legacy, true},
+		{"addr_send", addressArgs{From: address, To: &address}, false},
+		{"addr_send", addressArgs{From: address, To: &legacy}, true},
+		{"addr_batch", []common.Address{address, precomp}, false},
+		{"addr_batch", []common.Address{address, legacy}, true},
+	}
+	server := NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("addr", new(addressService)); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	for _, strict := range []bool{false, true} {
+		server.SetStrictAddresses(strict)
+		for i, tt := range tests {
+			var res interface{}
+			err := client.Call(&res, tt.method, tt.arg)
+			if !strict || !tt.legacy {
+				if err != nil {
+					t.Errorf("test %d (strict %v): unexpected error: %v", i, strict, err)
+				}
+				continue
+			}
+			if err == nil {
+				t.Errorf("test %d: legacy address accepted", i)
+				continue
+			}
+			if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32602 {
+				t.Errorf("test %d: error mismatch: have %v, want code -32602", i, err)
+			}
+		}
+	}
+}
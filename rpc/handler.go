@@ -327,6 +327,9 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
+	if err := h.reg.checkArgs(args); err != nil {
+		return msg.errorResponse(err)
+	}
 	start := time.Now()
 	answer := h.runMethod(cp.ctx, msg, callb, args)
 
@@ -369,6 +372,9 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	args = args[1:]
+	if err := h.reg.checkArgs(args); err != nil {
+		return msg.errorResponse(err)
+	}
 
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
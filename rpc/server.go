@@ -66,6 +66,15 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetStrictAddresses toggles rejecting calls whose arguments hold addresses that
+// look like zero-padded 20 byte legacy addresses, with an invalid params error.
+func (s *Server) SetStrictAddresses(strict bool) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+
+	s.services.strictAddresses = strict
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
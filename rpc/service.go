@@ -39,6 +39,8 @@ var (
 type serviceRegistry struct {
 	mu       sync.Mutex
 	services map[string]service
+
+	strictAddresses bool // Whether to reject legacy looking addresses in call arguments
 }
 
 // service represents a registered object.
@@ -103,6 +105,19 @@ func (r *serviceRegistry) callback(method string) *callback {
 	return r.services[elem[0]].callbacks[elem[1]]
 }
 
+// checkArgs validates the decoded arguments of a call according to the options
+// set on the registry.
+func (r *serviceRegistry) checkArgs(args []reflect.Value) error {
+	r.mu.Lock()
+	strict := r.strictAddresses
+	r.mu.Unlock()
+
+	if strict {
+		return checkAddresses(args)
+	}
+	return nil
+}
+
 // subscription returns a subscription callback in the given service.
 func (r *serviceRegistry) subscription(service, name string) *callback {
 	r.mu.Lock()
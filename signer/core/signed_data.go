@@ -539,11 +539,15 @@ func (typedData *TypedData) EncodePrimitiveValue(encType string, encValue interf
 	switch encType {
 	case "address":
 		stringValue, ok := encValue.(string)
-		if !ok || !common.IsHexAddress(stringValue) {
+		if !ok {
+			return nil, dataMismatchError(encType, encValue)
+		}
+		address, err := common.ParseAddress(stringValue)
+		if err != nil {
 			return nil, dataMismatchError(encType, encValue)
 		}
 		retval := make([]byte, 32)
-		copy(retval[7:], common.HexToAddress(stringValue).Bytes())
+		copy(retval[32-common.AddressLength:], address.Bytes())
 		return retval, nil
 	case "bool":
 		boolValue, ok := encValue.(bool)
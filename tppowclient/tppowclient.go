@@ -0,0 +1,290 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tppowclient provides typed wrappers for the mining and Tppow
+// consensus RPC APIs of a Luck node.
+package tppowclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/luck/go-luck"
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/consensus/tppow"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/rlp"
+	"github.com/luck/go-luck/rpc"
+)
+
+// Client defines typed wrappers for the miner and Tppow RPC APIs.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+// DialContext connects a client to the given URL with the given context.
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// Close terminates the underlying RPC connection.
+func (tc *Client) Close() {
+	tc.c.Close()
+}
+
+// Work is a first stage work package, searched for a nonce whose SealLuck stays
+// below Target.
+type Work struct {
+	Hash       common.Hash    // Seal hash identifying the work package
+	ParentHash common.Hash    // Parent of the block being sealed
+	Coinbase   common.Address // Beneficiary of the block being sealed
+	Time       uint64         // Timestamp of the block being sealed
+	Target     *big.Int       // First stage boundary, the block's DifficultyAlpha
+	Number     uint64         // Number of the block being sealed
+}
+
+// LuckWork is a second stage work package, searched for a nonce whose SealBlock
+// stays below Target.
+type LuckWork struct {
+	Hash   common.Hash   // Seal hash identifying the work package
+	Luck   *big.Int      // Luck derived from the submitted first nonce
+	Target *big.Int      // Second stage boundary, the block's DifficultyBeta
+	Header *types.Header // Header to search the second nonce for
+}
+
+// Mining reports whether the node is currently mining.
+func (tc *Client) Mining(ctx context.Context) (bool, error) {
+	var mining bool
+	err := tc.c.CallContext(ctx, &mining, "fort_mining")
+	return mining, err
+}
+
+// Coinbase retrieves the address mining rewards of the node are sent to.
+func (tc *Client) Coinbase(ctx context.Context) (common.Address, error) {
+	var coinbase common.Address
+	err := tc.c.CallContext(ctx, &coinbase, "fort_coinbase")
+	return coinbase, err
+}
+
+// Hashrate retrieves the combined hashrate of the local and remote miners.
+func (tc *Client) Hashrate(ctx context.Context) (uint64, error) {
+	var rate hexutil.Uint64
+	err := tc.c.CallContext(ctx, &rate, "fort_hashrate")
+	return uint64(rate), err
+}
+
+// LuckHashrate retrieves the first stage hashrate of the local miner.
+func (tc *Client) LuckHashrate(ctx context.Context) (uint64, error) {
+	var rate uint64
+	err := tc.c.CallContext(ctx, &rate, "tppow_getLuckHashrate")
+	return rate, err
+}
+
+// BlockHashrate retrieves the second stage hashrate of the local miner.
+func (tc *Client) BlockHashrate(ctx context.Context) (uint64, error) {
+	var rate uint64
+	err := tc.c.CallContext(ctx, &rate, "tppow_getBlockHashrate")
+	return rate, err
+}
+
+// StartMining starts the local miner with the given number of threads. If nil,
+// the number of threads is left to the node.
+func (tc *Client) StartMining(ctx context.Context, threads *int) error {
+	return tc.c.CallContext(ctx, nil, "miner_start", threads)
+}
+
+// StopMining stops the local miner.
+func (tc *Client) StopMining(ctx context.Context) error {
+	return tc.c.CallContext(ctx, nil, "miner_stop")
+}
+
+// SetCoinbase sets the address mining rewards of the node are sent to.
+func (tc *Client) SetCoinbase(ctx context.Context, coinbase common.Address) error {
+	var ok bool
+	if err := tc.c.CallContext(ctx, &ok, "miner_setEtherbase", coinbase); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("coinbase %x rejected", coinbase)
+	}
+	return nil
+}
+
+// PendingHeader retrieves the header of the block currently being mined, the
+// template remote work packages are derived from. Nodes don't disclose the
+// beneficiary of pending blocks, so the Coinbase of the header is left empty.
+func (tc *Client) PendingHeader(ctx context.Context) (*types.Header, error) {
+	var raw json.RawMessage
+	if err := tc.c.CallContext(ctx, &raw, "fort_getBlockByNumber", "pending", false); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, luck.NotFound
+	}
+	fields["miner"], _ = json.Marshal(common.Address{})
+
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	head := new(types.Header)
+	if err := json.Unmarshal(blob, head); err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// GetWork retrieves the current first stage work package.
+func (tc *Client) GetWork(ctx context.Context) (*Work, error) {
+	var res [6]string
+	if err := tc.c.CallContext(ctx, &res, "tppow_getWork"); err != nil {
+		return nil, err
+	}
+	var (
+		work = &Work{
+			Hash:       common.HexToHash(res[0]),
+			ParentHash: common.HexToHash(res[1]),
+			Coinbase:   common.HexToAddress(res[2]),
+		}
+		err error
+	)
+	if work.Time, err = hexutil.DecodeUint64(res[3]); err != nil {
+		return nil, fmt.Errorf("invalid work timestamp: %v", err)
+	}
+	if work.Target, err = hexutil.DecodeBig(res[4]); err != nil {
+		return nil, fmt.Errorf("invalid work target: %v", err)
+	}
+	if work.Number, err = hexutil.DecodeUint64(res[5]); err != nil {
+		return nil, fmt.Errorf("invalid work number: %v", err)
+	}
+	return work, nil
+}
+
+// SubmitLuck submits a first stage nonce for the work package with the given
+// hash, returning the second stage work package derived from its luck.
+func (tc *Client) SubmitLuck(ctx context.Context, firstNonce types.BlockNonce, hash common.Hash) (*LuckWork, error) {
+	var res [4]string
+	if err := tc.c.CallContext(ctx, &res, "tppow_submitLuck", firstNonce, hash); err != nil {
+		return nil, err
+	}
+	var (
+		work = &LuckWork{Hash: common.HexToHash(res[0])}
+		err  error
+	)
+	if work.Luck, err = hexutil.DecodeBig(res[1]); err != nil {
+		return nil, fmt.Errorf("invalid work luck: %v", err)
+	}
+	if work.Target, err = hexutil.DecodeBig(res[2]); err != nil {
+		return nil, fmt.Errorf("invalid work target: %v", err)
+	}
+	blob, err := hexutil.Decode(res[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid work header: %v", err)
+	}
+	work.Header = new(types.Header)
+	if err := rlp.DecodeBytes(blob, work.Header); err != nil {
+		return nil, fmt.Errorf("invalid work header: %v", err)
+	}
+	return work, nil
+}
+
+// SubmitWork submits both nonces of a sealed work package, reporting whether
+// the solution was accepted.
+func (tc *Client) SubmitWork(ctx context.Context, firstNonce, secondNonce types.BlockNonce, hash common.Hash) (bool, error) {
+	var ok bool
+	err := tc.c.CallContext(ctx, &ok, "tppow_submitWork", firstNonce, secondNonce, hash)
+	return ok, err
+}
+
+// SubmitHashrate reports the hashrate of a remote miner, identified by an id
+// unique between miners.
+func (tc *Client) SubmitHashrate(ctx context.Context, rate uint64, id common.Hash) (bool, error) {
+	var ok bool
+	err := tc.c.CallContext(ctx, &ok, "tppow_submitHashRate", hexutil.Uint64(rate), id)
+	return ok, err
+}
+
+// LuckAt retrieves the luck and difficulty parameters of the given block. The
+// number can be nil, in which case those of the latest block are returned.
+func (tc *Client) LuckAt(ctx context.Context, number *big.Int) (*tppow.LuckInfo, error) {
+	var info *tppow.LuckInfo
+	err := tc.c.CallContext(ctx, &info, "tppow_getLuck", toBlockNumArg(number))
+	return info, err
+}
+
+// VerifySeal checks the two-stage seal of a header, reporting the outcome of
+// every stage.
+func (tc *Client) VerifySeal(ctx context.Context, header *types.Header) (*tppow.SealVerification, error) {
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	var res *tppow.SealVerification
+	err = tc.c.CallContext(ctx, &res, "tppow_verifySeal", hexutil.Bytes(blob))
+	return res, err
+}
+
+// EstimateNextParams retrieves the difficulty parameters of a block built on
+// top of the current head.
+func (tc *Client) EstimateNextParams(ctx context.Context) (*tppow.NextParams, error) {
+	var params *tppow.NextParams
+	err := tc.c.CallContext(ctx, &params, "tppow_estimateNextParams")
+	return params, err
+}
+
+// CalcBeta calculates the second stage difficulty the given luck results in. If
+// basis is nil, the one estimated for the next block is used.
+func (tc *Client) CalcBeta(ctx context.Context, luck, basis *big.Int) (*big.Int, error) {
+	var beta hexutil.Big
+	err := tc.c.CallContext(ctx, &beta, "tppow_calcBeta", (*hexutil.Big)(luck), (*hexutil.Big)(basis))
+	return (*big.Int)(&beta), err
+}
+
+// RangeStats retrieves block time and luck statistics over the inclusive range
+// of blocks [from, to].
+func (tc *Client) RangeStats(ctx context.Context, from, to uint64) (*tppow.RangeStats, error) {
+	var stats *tppow.RangeStats
+	err := tc.c.CallContext(ctx, &stats, "tppow_getRangeStats", hexutil.Uint64(from), hexutil.Uint64(to))
+	return stats, err
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
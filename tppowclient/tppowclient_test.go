@@ -0,0 +1,218 @@
+// Copyright 2020 The go-luck Authors
+// This file is part of the go-luck library.
+//
+// The go-luck library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-luck library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-luck library. If not, see <http://www.gnu.org/licenses/>.
+
+package tppowclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/luck/go-luck/common"
+	"github.com/luck/go-luck/common/hexutil"
+	"github.com/luck/go-luck/consensus/ethash"
+	"github.com/luck/go-luck/consensus/tppow"
+	"github.com/luck/go-luck/core"
+	"github.com/luck/go-luck/core/rawdb"
+	"github.com/luck/go-luck/core/types"
+	"github.com/luck/go-luck/fort"
+	"github.com/luck/go-luck/node"
+	"github.com/luck/go-luck/params"
+	"github.com/luck/go-luck/rlp"
+	"github.com/luck/go-luck/rpc"
+)
+
+var testCoinbase = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314151617")
+
+func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
+	// Generate test chain.
+	genesis := &core.Genesis{Config: params.AllEthashProtocolChanges, Timestamp: 9000}
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := core.GenerateChain(genesis.Config, genesis.ToBlock(db), tppow.NewFaker(), db, 4, nil)
+
+	// Start Luck service.
+	var fortservice *fort.Luck
+	n, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create test node: %v", err)
+	}
+	n.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		config := &fort.Config{Genesis: genesis}
+		config.Ethash.PowMode = ethash.ModeFake
+		config.Miner.Etherbase = testCoinbase
+		fortservice, err = fort.New(ctx, config)
+		return fortservice, err
+	})
+	// Import the test chain.
+	if err := n.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	if _, err := fortservice.BlockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	return n, blocks
+}
+
+func TestMinerStatus(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	tc, ctx := NewClient(client), context.Background()
+	if mining, err := tc.Mining(ctx); err != nil || mining {
+		t.Errorf("mining status mismatch: have %v/%v, want false", mining, err)
+	}
+	if coinbase, err := tc.Coinbase(ctx); err != nil || coinbase != testCoinbase {
+		t.Errorf("coinbase mismatch: have %x/%v, want %x", coinbase, err, testCoinbase)
+	}
+	if rate, err := tc.Hashrate(ctx); err != nil || rate != 0 {
+		t.Errorf("hashrate mismatch: have %d/%v, want 0", rate, err)
+	}
+	if rate, err := tc.LuckHashrate(ctx); err != nil || rate != 0 {
+		t.Errorf("luck hashrate mismatch: have %d/%v, want 0", rate, err)
+	}
+	if rate, err := tc.BlockHashrate(ctx); err != nil || rate != 0 {
+		t.Errorf("block hashrate mismatch: have %d/%v, want 0", rate, err)
+	}
+	coinbase := common.HexToAddress("0xff")
+	if err := tc.SetCoinbase(ctx, coinbase); err != nil {
+		t.Fatalf("failed to set coinbase: %v", err)
+	}
+	if have, err := tc.Coinbase(ctx); err != nil || have != coinbase {
+		t.Errorf("updated coinbase mismatch: have %x/%v, want %x", have, err, coinbase)
+	}
+	if header, err := tc.PendingHeader(ctx); err != nil || header.Number.Uint64() != 5 {
+		t.Errorf("pending header mismatch: have %v/%v, want block 5", header, err)
+	}
+}
+
+func TestTppowInspection(t *testing.T) {
+	backend, blocks := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Stop()
+	defer client.Close()
+
+	tc, ctx := NewClient(client), context.Background()
+	header := blocks[1].Header()
+
+	info, err := tc.LuckAt(ctx, header.Number)
+	if err != nil {
+		t.Fatalf("failed to retrieve luck: %v", err)
+	}
+	if info.Hash != header.Hash() || info.Basis.ToInt().Cmp(header.Basis) != 0 || info.DifficultyAlpha.ToInt().Cmp(header.DifficultyAlpha) != 0 {
+		t.Errorf("luck info mismatch: have %+v, want header %x", info, header.Hash())
+	}
+	if info, err := tc.LuckAt(ctx, nil); err != nil || uint64(info.Number) != 4 {
+		t.Errorf("head luck mismatch: have %+v/%v, want block 4", info, err)
+	}
+	res, err := tc.VerifySeal(ctx, header)
+	if err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	if res.Valid {
+		t.Errorf("unsealed header reported valid")
+	}
+	next, err := tc.EstimateNextParams(ctx)
+	if err != nil || uint64(next.Number) != 5 {
+		t.Fatalf("next params mismatch: have %+v/%v, want block 5", next, err)
+	}
+	if beta, err := tc.CalcBeta(ctx, new(big.Int), nil); err != nil || beta.Cmp(next.Basis.ToInt()) != 0 {
+		t.Errorf("beta mismatch: have %v/%v, want %v", beta, err, next.Basis)
+	}
+	stats, err := tc.RangeStats(ctx, 1, 4)
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	if uint64(stats.From) != 1 || uint64(stats.To) != 4 {
+		t.Errorf("stats range mismatch: have [%d, %d], want [1, 4]", stats.From, stats.To)
+	}
+}
+
+// testWorkAPI serves fixed work packages in place of a sealing node.
+type testWorkAPI struct {
+	header *types.Header
+}
+
+func (api *testWorkAPI) GetWork() [6]string {
+	return [6]string{
+		common.HexToHash("0x01").Hex(),
+		api.header.ParentHash.Hex(),
+		api.header.Coinbase.Hex(),
+		hexutil.EncodeUint64(api.header.Time),
+		hexutil.EncodeBig(api.header.DifficultyAlpha),
+		hexutil.EncodeBig(api.header.Number),
+	}
+}
+
+func (api *testWorkAPI) SubmitLuck(firstNonce types.BlockNonce, hash common.Hash) [4]string {
+	blob, _ := rlp.EncodeToBytes(api.header)
+	return [4]string{hash.Hex(), hexutil.EncodeBig(api.header.Lucky), hexutil.EncodeBig(api.header.DifficultyBeta), hexutil.Encode(blob)}
+}
+
+func (api *testWorkAPI) SubmitWork(firstNonce, secondNonce types.BlockNonce, hash common.Hash) bool {
+	return firstNonce == api.header.FirstNonce && secondNonce == api.header.SecondNonce
+}
+
+// Tests that remote work packages are decoded into their typed form.
+func TestRemoteWork(t *testing.T) {
+	header := &types.Header{
+		ParentHash:      common.HexToHash("0x02"),
+		Coinbase:        testCoinbase,
+		Number:          big.NewInt(7),
+		Time:            9000,
+		Lucky:           big.NewInt(12345),
+		Basis:           big.NewInt(1000),
+		DifficultyAlpha: big.NewInt(2000),
+		DifficultyBeta:  big.NewInt(3000),
+		Difficulty:      big.NewInt(4000),
+		FirstNonce:      types.EncodeNonce(1),
+		SecondNonce:     types.EncodeNonce(2),
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("tppow", &testWorkAPI{header}); err != nil {
+		t.Fatalf("failed to register test API: %v", err)
+	}
+	tc, ctx := NewClient(rpc.DialInProc(server)), context.Background()
+	defer tc.Close()
+
+	work, err := tc.GetWork(ctx)
+	if err != nil {
+		t.Fatalf("failed to fetch work: %v", err)
+	}
+	want := &Work{common.HexToHash("0x01"), header.ParentHash, header.Coinbase, header.Time, header.DifficultyAlpha, 7}
+	if work.Hash != want.Hash || work.ParentHash != want.ParentHash || work.Coinbase != want.Coinbase ||
+		work.Time != want.Time || work.Target.Cmp(want.Target) != 0 || work.Number != want.Number {
+		t.Errorf("work mismatch: have %+v, want %+v", work, want)
+	}
+	luck, err := tc.SubmitLuck(ctx, header.FirstNonce, work.Hash)
+	if err != nil {
+		t.Fatalf("failed to submit luck: %v", err)
+	}
+	if luck.Hash != work.Hash || luck.Luck.Cmp(header.Lucky) != 0 || luck.Target.Cmp(header.DifficultyBeta) != 0 {
+		t.Errorf("luck work mismatch: have %+v", luck)
+	}
+	if luck.Header.Hash() != header.Hash() {
+		t.Errorf("luck work header mismatch: have %x, want %x", luck.Header.Hash(), header.Hash())
+	}
+	if ok, err := tc.SubmitWork(ctx, header.FirstNonce, header.SecondNonce, work.Hash); err != nil || !ok {
+		t.Errorf("valid work rejected: %v/%v", ok, err)
+	}
+	if ok, err := tc.SubmitWork(ctx, header.SecondNonce, header.FirstNonce, work.Hash); err != nil || ok {
+		t.Errorf("invalid work accepted: %v/%v", ok, err)
+	}
+}